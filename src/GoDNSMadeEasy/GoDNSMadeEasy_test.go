@@ -1,11 +1,24 @@
 package GoDNSMadeEasy
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -85,8 +98,15 @@ func TestRecords(t *testing.T) {
 				if err != nil {
 					t.Error(fmt.Sprintf("(update) %s %s: %s", thisRecord.Type, thisRecord.Name, err))
 				}
-				//Because DNS Made Easy does not return the new record, and doesn't give a method for retrieving a single record, this is
-				//all we can do here
+				//DNS Made Easy does not return the new record, so fetch it again to check the update applied
+				updatedRecord, err := DMEClient.Record(DomainID, thisRecord.ID)
+				if err != nil {
+					t.Error(fmt.Sprintf("(fetch) %s %s: %s", thisRecord.Type, thisRecord.Name, err))
+				}
+				mismatches := compareRecords(&thisRecord, updatedRecord)
+				if len(mismatches) > 0 {
+					t.Error(fmt.Sprintf("(update) %s %s: records do not match: %s", thisRecord.Type, thisRecord.Name, strings.Join(mismatches, ",")))
+				}
 			}
 		}
 	}
@@ -129,6 +149,14 @@ func TestDomainRead(t *testing.T) {
 		t.Errorf("direct fetch domain IDs do not match (%v, %v)", newDomain, fetchDirect.ID)
 	}
 
+	//And by its name, which shouldn't care about case or trailing dots
+	fetchByName, err := DMEClient.DomainByName(strings.ToUpper(newDomain.Name) + ".")
+	if err != nil {
+		t.Error("fetch by name error: ", err)
+	} else if fetchByName.ID != newDomainID {
+		t.Errorf("fetch by name domain IDs do not match (%v, %v)", newDomainID, fetchByName.ID)
+	}
+
 	fullDomainList, err := DMEClient.Domains()
 	if err != nil {
 		t.Error("full domain fetch error: ", err)
@@ -290,6 +318,135 @@ func TestIPSets(t *testing.T) {
 
 }
 
+// TestTransferACLs creates a transfer ACL, assigns it to a domain, checks the export picks it up, then updates and deletes it
+func TestTransferACLs(t *testing.T) {
+	DMEClient, err := newClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newACL, err := DMEClient.AddTransferACL(TransferACL{
+		Name: fmt.Sprintf("testacl-%v", time.Now().UnixNano()),
+		Ips:  []string{"127.0.0.1", "127.0.0.2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	allACLs, err := DMEClient.TransferACLs()
+	if err != nil {
+		t.Error(err)
+	}
+	var foundACL bool
+	for _, thisACL := range allACLs {
+		if thisACL.ID == newACL.ID {
+			foundACL = true
+			break
+		}
+	}
+	if !foundACL {
+		t.Error("could not find our new transfer ACL in the transfer ACL list")
+	}
+
+	//Assign the ACL to a domain, and make sure the export resolves it
+	newDomain, err := generateTestDomain(DMEClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newDomain.TransferAclID = newACL.ID
+	err = DMEClient.UpdateDomain(newDomain)
+	if err != nil {
+		t.Error(err)
+	}
+	allDomains, err := DMEClient.ExportAllDomains()
+	if err != nil {
+		t.Error(err)
+	} else if thisExport, ok := (*allDomains)[newDomain.Name]; !ok || thisExport.TransferACL == nil || thisExport.TransferACL.ID != newACL.ID {
+		t.Error("export did not include our transfer ACL")
+	}
+
+	//Take the ACL back off the domain so that it can be deleted
+	newDomain.TransferAclID = 0
+	DMEClient.UpdateDomain(newDomain)
+
+	newACL.Ips = append(newACL.Ips, "127.0.0.3")
+	err = DMEClient.UpdateTransferACL(newACL)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = DMEClient.DeleteTransferACL(newACL.ID)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// TestTemplates creates a template with a record, applies it to a couple of domains, then cleans it all up
+func TestTemplates(t *testing.T) {
+	DMEClient, err := newClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newTemplate, err := DMEClient.AddTemplate(Template{
+		Name: fmt.Sprintf("testtemplate-%v", time.Now().UnixNano()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	templateRecord, err := DMEClient.AddTemplateRecord(newTemplate.ID, &Record{
+		Name:        "www",
+		Type:        "A",
+		Value:       "127.0.0.1",
+		TTL:         1800,
+		GtdLocation: "DEFAULT",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	templateRecord.Value = "127.0.0.2"
+	err = DMEClient.UpdateTemplateRecord(newTemplate.ID, templateRecord)
+	if err != nil {
+		t.Error(err)
+	}
+	templateRecords, err := DMEClient.TemplateRecords(newTemplate.ID)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(templateRecords) != 1 || templateRecords[0].Value != "127.0.0.2" {
+		t.Errorf("unexpected template records %+v", templateRecords)
+	}
+
+	//Stamp the template onto two new domains
+	var domainIDs []int
+	for i := 0; i < 2; i++ {
+		newDomain, err := generateTestDomain(DMEClient)
+		if err != nil {
+			t.Fatal(err)
+		}
+		domainIDs = append(domainIDs, newDomain.ID)
+	}
+	err = DMEClient.ApplyTemplate(newTemplate.ID, domainIDs)
+	if err != nil {
+		t.Error(err)
+	}
+	for _, domainID := range domainIDs {
+		fetchedDomain, err := DMEClient.Domain(domainID)
+		if err != nil {
+			t.Error(err)
+		} else if fetchedDomain.TemplateID != newTemplate.ID {
+			t.Errorf("template was not applied to domain %v", domainID)
+		}
+	}
+
+	err = DMEClient.DeleteTemplateRecord(newTemplate.ID, templateRecord.ID)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
 func TestSecondaryDomain(t *testing.T) {
 	DMEClient, err := newClient()
 	if err != nil {
@@ -351,6 +508,64 @@ func TestSecondaryDomain(t *testing.T) {
 
 }
 
+// TestFolders creates a folder, moves a domain into it, updates it, then moves the domain back out and deletes the folder
+func TestFolders(t *testing.T) {
+	DMEClient, err := newClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newFolder, err := DMEClient.AddFolder(FolderDetail{
+		Name: fmt.Sprintf("testfolder-%v", time.Now().UnixNano()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newDomain, err := generateTestDomain(DMEClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	originalFolderID := newDomain.FolderID
+
+	err = DMEClient.MoveDomainToFolder(newDomain, newFolder.ID)
+	if err != nil {
+		t.Error(err)
+	}
+	if newDomain.FolderID != newFolder.ID {
+		t.Errorf("domain folder ID was not updated (%v %v)", newDomain.FolderID, newFolder.ID)
+	}
+
+	fetchedFolder, err := DMEClient.Folder(newFolder.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var foundDomain bool
+	for _, domainID := range fetchedFolder.Domains {
+		if domainID == newDomain.ID {
+			foundDomain = true
+		}
+	}
+	if !foundDomain {
+		t.Error("could not find our domain in the folder")
+	}
+
+	fetchedFolder.Name += "updated"
+	err = DMEClient.UpdateFolder(fetchedFolder)
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = DMEClient.MoveDomainToFolder(newDomain, originalFolderID)
+	if err != nil {
+		t.Error(err)
+	}
+	err = DMEClient.DeleteFolder(newFolder.ID)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
 // TestExportAll runs the ExportAllDomains() function and sees if it returns any errors. That's about it.
 func TestExportAll(t *testing.T) {
 	DMEClient, err := newClient()
@@ -363,6 +578,1215 @@ func TestExportAll(t *testing.T) {
 	}
 }
 
+// TestContextCancelled checks that a cancelled context stops a request before it is sent, including the retry loop used by deletes
+func TestContextCancelled(t *testing.T) {
+	DMEClient, closeServer := newTestServerClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("request to %s was sent with a cancelled context", r.URL.Path)
+	}))
+	defer closeServer()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := DMEClient.DomainsContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled from DomainsContext, got %v", err)
+	}
+
+	err = DMEClient.DeleteDomainContext(ctx, 1, 2*time.Minute)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled from DeleteDomainContext, got %v", err)
+	}
+}
+
+// TestAPIErrors runs canned DNS Made Easy error responses through a local server, and checks they are classified correctly
+func TestAPIErrors(t *testing.T) {
+	responses := map[string]struct {
+		status int
+		body   string
+		is     error
+	}{
+		"/notfound":  {http.StatusNotFound, "", ErrNotFound},
+		"/forbidden": {http.StatusForbidden, "", ErrForbidden},
+		"/pending":   {http.StatusBadRequest, "{error: [\"Cannot delete a domain that is pending a create or delete action.\"]}", ErrPendingAction},
+		"/ratelimit": {http.StatusBadRequest, `{"error": ["Rate limit exceeded"]}`, ErrRateLimited},
+		"/duplicate": {http.StatusBadRequest, `{"error": ["Record with this type (A), name (www), and value (127.0.0.1) already exists."]}`, ErrDuplicate},
+	}
+
+	DMEClient, closeServer := newTestServerClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		thisResponse := responses[r.URL.Path]
+		w.WriteHeader(thisResponse.status)
+		w.Write([]byte(thisResponse.body))
+	}))
+	defer closeServer()
+
+	for endpoint, expected := range responses {
+		req, err := DMEClient.newRequest(context.Background(), "GET", strings.TrimPrefix(endpoint, "/"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = DMEClient.doDMERequest(req, nil)
+
+		var apiError *APIError
+		if !errors.As(err, &apiError) {
+			t.Errorf("%s: expected an *APIError, got %T (%v)", endpoint, err, err)
+			continue
+		}
+		if apiError.StatusCode != expected.status || apiError.Method != "GET" || apiError.Endpoint != strings.TrimPrefix(endpoint, "/") {
+			t.Errorf("%s: unexpected error details %+v", endpoint, apiError)
+		}
+		for _, sentinel := range []error{ErrNotFound, ErrForbidden, ErrPendingAction, ErrRateLimited, ErrDuplicate} {
+			if errors.Is(err, sentinel) != (sentinel == expected.is) {
+				t.Errorf("%s: errors.Is(err, %v) = %v", endpoint, sentinel, errors.Is(err, sentinel))
+			}
+		}
+	}
+}
+
+// TestRateLimit checks that the rate limit headers are tracked, and that RateLimitBlock holds requests back once the reserve is reached
+func TestRateLimit(t *testing.T) {
+	var requestCount int
+	var mu sync.Mutex
+	DMEClient, closeServer := newTestServerClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requestCount++
+		remaining := 3 - requestCount
+		mu.Unlock()
+		w.Header().Set("x-dnsme-requestLimit", "150")
+		w.Header().Set("x-dnsme-requestsRemaining", fmt.Sprint(remaining))
+		w.Write([]byte(`{"data": [], "page": 0, "totalPages": 1, "totalRecords": 0}`))
+	}))
+	defer closeServer()
+
+	if !DMEClient.RateLimit().Updated.IsZero() {
+		t.Error("rate limit should be unknown before any requests are made")
+	}
+
+	DMEClient.RateLimitBehaviour = RateLimitBlock
+	DMEClient.RateLimitReserve = 1
+	DMEClient.RateLimitWindow = 200 * time.Millisecond
+
+	//The first two requests take us down to the reserve, so shouldn't be held back
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		_, err := DMEClient.Domains()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if time.Since(start) >= DMEClient.RateLimitWindow {
+		t.Error("requests were held back before reaching the reserve")
+	}
+	if limit := DMEClient.RateLimit(); limit.Limit != 150 || limit.Remaining != 1 {
+		t.Errorf("unexpected rate limit %+v", limit)
+	}
+
+	//The third has to wait for the window to pass, unless the context gives up first
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := DMEClient.DomainsContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the rate limited request to time out, got %v", err)
+	}
+
+	start = time.Now()
+	_, err = DMEClient.Domains()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) < DMEClient.RateLimitWindow/2 {
+		t.Error("request was not held back after reaching the reserve")
+	}
+}
+
+// TestRetry checks that temporary failures are retried with the same body and a fresh signature, and that other failures are not
+func TestRetry(t *testing.T) {
+	var attempts int
+	DMEClient, closeServer := newTestServerClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get("x-dnsme-hmac") == "" || r.Header.Get("x-dnsme-requestDate") == "" {
+			t.Errorf("attempt %v was not signed", attempts)
+		}
+
+		switch {
+		case r.URL.Path == "/dns/managed/1":
+			w.WriteHeader(http.StatusNotFound)
+		case attempts == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case attempts == 2:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": ["Rate limit exceeded"]}`))
+		default:
+			//Echo the record back, like DNS Made Easy does
+			w.Write(body)
+		}
+	}))
+	defer closeServer()
+
+	DMEClient.Retry = RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+	}
+
+	newRecord, err := DMEClient.AddRecord(2, &Record{Name: "retry", Type: "A", Value: "127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %v", attempts)
+	}
+	if newRecord.Name != "retry" || newRecord.Value != "127.0.0.1" {
+		t.Errorf("request body was not re-sent intact: %+v", newRecord)
+	}
+
+	attempts = 0
+	_, err = DMEClient.Domain(1)
+	if !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("not found error should not be retried, but got %v attempts", attempts)
+	}
+}
+
+// TestPagination checks that every page of a list is fetched, and that EachRecord stops when asked to
+func TestPagination(t *testing.T) {
+	var requests int
+	DMEClient, closeServer := newTestServerClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Query().Get("rows") != "2" {
+			t.Errorf("expected 2 rows per page, got %s", r.URL.Query().Get("rows"))
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		fmt.Fprintf(w, `{"page": %v, "totalPages": 3, "totalRecords": 5, "data": [{"id": %v}, {"id": %v}]}`, page, page*2+1, page*2+2)
+	}))
+	defer closeServer()
+	DMEClient.PageSize = 2
+
+	allRecords, err := DMEClient.Records(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(allRecords) != 6 || allRecords[5].ID != 6 || requests != 3 {
+		t.Errorf("expected 6 records from 3 requests, got %v records from %v requests", len(allRecords), requests)
+	}
+
+	requests = 0
+	stopHere := errors.New("stop")
+	var seen int
+	err = DMEClient.EachRecord(1, func(thisRecord Record) error {
+		seen++
+		if thisRecord.ID == 3 {
+			return stopHere
+		}
+		return nil
+	})
+	if err != stopHere {
+		t.Errorf("expected EachRecord to return the callback's error, got %v", err)
+	}
+	if seen != 3 || requests != 2 {
+		t.Errorf("expected to stop after 3 records from 2 requests, got %v records from %v requests", seen, requests)
+	}
+}
+
+// TestRecordLookup checks that RecordsFilter and RecordFiltered send their filter to DNS Made Easy, and that Record finds a single record or
+// reports it as not found
+func TestRecordLookup(t *testing.T) {
+	DMEClient, closeServer := newTestServerClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("recordName") == "_acme-challenge" && r.URL.Query().Get("type") == "TXT" {
+			w.Write([]byte(`{"page": 0, "totalPages": 1, "totalRecords": 1, "data": [{"id": 3, "name": "_acme-challenge", "type": "TXT"}]}`))
+			return
+		}
+		if r.URL.Query().Get("recordName") != "" || r.URL.Query().Get("type") != "" {
+			t.Errorf("unexpected filter %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"page": 0, "totalPages": 1, "totalRecords": 2, "data": [{"id": 1, "name": "www"}, {"id": 2, "name": "mail"}]}`))
+	}))
+	defer closeServer()
+
+	filtered, err := DMEClient.RecordsFilter(1, RecordFilter{Name: "_acme-challenge", Type: "TXT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filtered) != 1 || filtered[0].ID != 3 {
+		t.Errorf("unexpected filtered records %+v", filtered)
+	}
+
+	thisRecord, err := DMEClient.Record(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thisRecord.Name != "mail" {
+		t.Errorf("fetched the wrong record: %+v", thisRecord)
+	}
+
+	_, err = DMEClient.Record(1, 99)
+	if !IsNotFound(err) {
+		t.Errorf("expected a not found error for a missing record, got %v", err)
+	}
+
+	thisRecord, err = DMEClient.RecordFiltered(1, 3, RecordFilter{Name: "_acme-challenge", Type: "TXT"})
+	if err != nil || thisRecord.Name != "_acme-challenge" {
+		t.Errorf("unexpected filtered record %+v, error %v", thisRecord, err)
+	}
+	_, err = DMEClient.RecordFiltered(1, 1, RecordFilter{Name: "_acme-challenge", Type: "TXT"})
+	if !IsNotFound(err) {
+		t.Errorf("expected a not found error for a record outside the filter, got %v", err)
+	}
+}
+
+// TestBulkRecords checks that bulk record requests are split into chunks, and that a failed chunk doesn't stop the others
+func TestBulkRecords(t *testing.T) {
+	var chunks int
+	DMEClient, closeServer := newTestServerClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chunks++
+		var chunk []Record
+		json.NewDecoder(r.Body).Decode(&chunk)
+		if len(chunk) > 2 {
+			t.Errorf("chunk of %v records is bigger than BulkChunkSize", len(chunk))
+		}
+		if chunk[0].Name == "dupe" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": ["Record with this type (A), name (dupe), and value (127.0.0.1) already exists."]}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/dns/managed/1/records/createMulti":
+			json.NewEncoder(w).Encode(chunk)
+		case "/dns/managed/1/records/updateMulti":
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer closeServer()
+	DMEClient.BulkChunkSize = 2
+
+	var newRecords []Record
+	for _, name := range []string{"a", "b", "dupe", "c", "d"} {
+		newRecords = append(newRecords, Record{Name: name, Type: RecordA, Value: "127.0.0.1"})
+	}
+	created, err := DMEClient.AddRecords(1, newRecords)
+	if chunks != 3 || len(created) != 3 {
+		t.Errorf("expected 3 records back from 3 chunks, got %v records from %v chunks", len(created), chunks)
+	}
+	var bulkError *BulkError
+	if !errors.As(err, &bulkError) {
+		t.Fatalf("expected a *BulkError, got %v", err)
+	}
+	if len(bulkError.Chunks) != 1 || bulkError.Chunks[0].Offset != 2 || len(bulkError.Chunks[0].Records) != 2 {
+		t.Errorf("unexpected failed chunks %+v", bulkError.Chunks)
+	}
+	if !IsDuplicate(err) {
+		t.Error("bulk error should match the failed chunk's error")
+	}
+
+	chunks = 0
+	err = DMEClient.UpdateRecords(1, newRecords[:2])
+	if err != nil || chunks != 1 {
+		t.Errorf("expected a single successful chunk, got %v chunks and error %v", chunks, err)
+	}
+}
+
+// TestNameLookup checks that domain and secondary domain names are normalised, and that unknown names give a *NotFoundError
+func TestNameLookup(t *testing.T) {
+	DMEClient, closeServer := newTestServerClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/dns/managed/name":
+			if r.URL.Query().Get("domainname") == "example.org" {
+				w.Write([]byte(`{"id": 1, "name": "example.org"}`))
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		case "/dns/secondary":
+			w.Write([]byte(`{"page": 0, "totalPages": 1, "totalRecords": 2, "data": [{"id": 2, "name": "example.net"}, {"id": 3, "name": "Example.COM"}]}`))
+		}
+	}))
+	defer closeServer()
+
+	thisDomain, err := DMEClient.DomainByName("Example.ORG.")
+	if err != nil || thisDomain.ID != 1 {
+		t.Errorf("expected domain 1, got %+v (%v)", thisDomain, err)
+	}
+	thisSecondary, err := DMEClient.SecondaryDomainByName("example.com.")
+	if err != nil || thisSecondary.ID != 3 {
+		t.Errorf("expected secondary domain 3, got %+v (%v)", thisSecondary, err)
+	}
+
+	for _, err := range []error{
+		func() error { _, err := DMEClient.DomainByName("missing.org"); return err }(),
+		func() error { _, err := DMEClient.SecondaryDomainByName("missing.org"); return err }(),
+	} {
+		var notFound *NotFoundError
+		if !errors.As(err, &notFound) || notFound.Name != "missing.org" || !IsNotFound(err) {
+			t.Errorf("expected a *NotFoundError for missing.org, got %v", err)
+		}
+	}
+}
+
+// TestFailover checks the failover IP list helpers, and that exports only fetch failover settings for records that use them
+func TestFailover(t *testing.T) {
+	thisFailover := &Failover{}
+	err := thisFailover.SetIPs([]string{"127.0.0.1", "127.0.0.2", "127.0.0.3", "127.0.0.4", "127.0.0.5", "127.0.0.6"})
+	if err == nil {
+		t.Error("expected an error setting 6 failover IPs")
+	}
+	thisFailover.SetIPs([]string{"127.0.0.1", "127.0.0.2"})
+	if thisFailover.IP2 != "127.0.0.2" || thisFailover.IP3 != "" || len(thisFailover.IPs()) != 2 {
+		t.Errorf("failover IPs were not set correctly: %+v", thisFailover)
+	}
+
+	var monitorRequests []string
+	DMEClient, closeServer := newTestServerClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/dns/managed/":
+			w.Write([]byte(`{"page": 0, "totalPages": 1, "data": [{"id": 1, "name": "example.org"}]}`))
+		case r.URL.Path == "/dns/managed/1/records":
+			w.Write([]byte(`{"page": 0, "totalPages": 1, "data": [{"id": 10, "name": "www", "monitor": true}, {"id": 11, "name": "mail"}, {"id": 12, "name": "api", "failover": true}]}`))
+		case strings.HasPrefix(r.URL.Path, "/monitor/"):
+			monitorRequests = append(monitorRequests, r.URL.Path)
+			fmt.Fprintf(w, `{"recordId": %s, "monitor": true, "protocolId": 3, "ip1": "127.0.0.1"}`, strings.TrimPrefix(r.URL.Path, "/monitor/"))
+		default:
+			w.Write([]byte(`{"page": 0, "totalPages": 1, "data": []}`))
+		}
+	}))
+	defer closeServer()
+
+	allDomains, err := DMEClient.ExportAllDomainsWithOptions(ExportOptions{IncludeFailover: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	domainFailover := (*allDomains)["example.org"].Failover
+	if len(monitorRequests) != 2 || len(domainFailover) != 2 || domainFailover[10].Protocol != MonitorHTTP || domainFailover[12].RecordID != 12 {
+		t.Errorf("unexpected failover export %v from requests %v", domainFailover, monitorRequests)
+	}
+}
+
+// TestGTD checks that GTD locations are only allowed on domains with GTD enabled, and that records are grouped by their location
+func TestGTD(t *testing.T) {
+	var recordsSent int
+	DMEClient, closeServer := newTestServerClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/dns/managed/1":
+			w.Write([]byte(`{"id": 1, "name": "example.org"}`))
+		case r.URL.Path == "/dns/managed/2":
+			w.Write([]byte(`{"id": 2, "name": "example.net", "gtdEnabled": true}`))
+		case r.Method == "GET":
+			w.Write([]byte(`{"page": 0, "totalPages": 1, "data": [{"id": 1, "name": "www", "gtdLocation": "DEFAULT"}, {"id": 2, "name": "www", "gtdLocation": "EUROPE"}, {"id": 3, "name": "mail"}]}`))
+		default:
+			recordsSent++
+			body, _ := ioutil.ReadAll(r.Body)
+			w.Write(body)
+		}
+	}))
+	defer closeServer()
+
+	_, err := DMEClient.AddRecord(1, &Record{Name: "www", Type: "A", Value: "127.0.0.1", GtdLocation: GTDEurope})
+	if !errors.Is(err, ErrGTDNotEnabled) {
+		t.Errorf("expected ErrGTDNotEnabled, got %v", err)
+	}
+	err = DMEClient.UpdateRecord(1, &Record{ID: 1, Name: "www", Type: "A", Value: "127.0.0.1", GtdLocation: "MARS"})
+	if err == nil {
+		t.Error("expected an error for an unknown GTD location")
+	}
+	if recordsSent != 0 {
+		t.Errorf("%v invalid records were sent to DNS Made Easy", recordsSent)
+	}
+
+	_, err = DMEClient.AddRecord(1, &Record{Name: "www", Type: "A", Value: "127.0.0.1", GtdLocation: GTDDefault})
+	if err != nil {
+		t.Error(err)
+	}
+	_, err = DMEClient.AddRecord(2, &Record{Name: "www", Type: "A", Value: "127.0.0.1", GtdLocation: GTDEurope})
+	if err != nil {
+		t.Error(err)
+	}
+
+	byLocation, err := DMEClient.RecordsByGTDLocation(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byLocation[GTDDefault]) != 2 || len(byLocation[GTDEurope]) != 1 || byLocation[GTDEurope][0].ID != 2 {
+		t.Errorf("records were not grouped by location: %+v", byLocation)
+	}
+}
+
+// TestContactLists checks that a contact list can be found by name and used for failover notifications
+func TestContactLists(t *testing.T) {
+	DMEClient, closeServer := newTestServerClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/contactList" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"page": 0, "totalPages": 1, "data": [{"id": 5, "name": "Default", "emails": ["noc@example.org"]}, {"id": 6, "name": "On Call"}]}`))
+	}))
+	defer closeServer()
+
+	allContactLists, err := DMEClient.ContactLists()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(allContactLists) != 2 || allContactLists[0].Emails[0] != "noc@example.org" {
+		t.Errorf("unexpected contact lists %+v", allContactLists)
+	}
+
+	thisFailover := &Failover{RecordID: 10, Monitor: true}
+	err = DMEClient.SetFailoverContactList(thisFailover, "on call")
+	if err != nil || thisFailover.ContactListID != 6 {
+		t.Errorf("expected contact list 6, got %v (%v)", thisFailover.ContactListID, err)
+	}
+
+	err = DMEClient.SetFailoverContactList(thisFailover, "missing")
+	var notFound *NotFoundError
+	if !errors.As(err, &notFound) || thisFailover.ContactListID != 6 {
+		t.Errorf("expected a *NotFoundError and an unchanged failover, got %v", err)
+	}
+}
+
+// TestUsage checks that monthly usage is requested for the right month, and is added up by domain name
+func TestUsage(t *testing.T) {
+	DMEClient, closeServer := newTestServerClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/dns/managed/":
+			w.Write([]byte(`{"page": 0, "totalPages": 1, "data": [{"id": 1, "name": "example.org"}, {"id": 2, "name": "example.net"}]}`))
+		case "/usageApi/queriesApi/2017/3":
+			w.Write([]byte(`{"page": 0, "totalPages": 1, "data": [
+				{"total": 100, "month": 3, "year": 2017, "primaryEntity": "domain", "primaryEntityId": 1},
+				{"total": 50, "month": 3, "year": 2017, "primaryEntity": "domain", "primaryEntityId": 1, "secondaryEntity": "location", "secondaryEntityId": 4},
+				{"total": 7, "month": 3, "year": 2017, "primaryEntity": "secondary", "primaryEntityId": 2}
+			]}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer closeServer()
+
+	byDomain, err := DMEClient.UsageByDomain(2017, time.March)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byDomain) != 2 || byDomain["example.org"] != 150 || byDomain["example.net"] != 0 {
+		t.Errorf("unexpected usage by domain %v", byDomain)
+	}
+}
+
+// TestRecordValidate checks that badly formed records are caught by Validate, and never sent to DNS Made Easy
+func TestRecordValidate(t *testing.T) {
+	for _, thisRecord := range getTestRecords(false) {
+		if err := thisRecord.Validate(); err != nil {
+			t.Errorf("%s %s: %v", thisRecord.Type, thisRecord.Name, err)
+		}
+	}
+
+	longText := strings.Repeat("v=spf1 include:example.org ", 20)
+	for _, thisRecord := range []Record{
+		{Type: RecordTXT, Value: longText},
+		{Type: RecordTXT, Value: `"unbalanced`},
+		{Type: RecordTXT, Value: `"` + strings.Repeat("a", 256) + `"`},
+		{Type: "BOGUS", Value: "127.0.0.1"},
+		{Type: RecordA, Value: "::1"},
+		{Type: RecordAAAA, Value: "127.0.0.1"},
+		{Type: RecordA, Value: "127.0.0.1", MxLevel: 10},
+		{Type: RecordCNAME, Value: "not a hostname."},
+		{Type: RecordSRV, Value: "example.org.", Port: 70000},
+		{Type: RecordCAA, Value: "letsencrypt.org", CaaType: "issue", IssuerCritical: 1},
+		{Type: RecordCAA, Value: "letsencrypt.org", CaaType: "bogus"},
+		{Type: RecordHTTPRED, Value: "example.org"},
+	} {
+		if err := thisRecord.Validate(); !errors.Is(err, ErrInvalidRecord) {
+			t.Errorf("expected %s %q to be invalid, got %v", thisRecord.Type, thisRecord.Value, err)
+		}
+	}
+
+	quoted := Record{Type: RecordTXT, Value: QuoteTXT(longText + `"quoted"`)}
+	if err := quoted.Validate(); err != nil {
+		t.Errorf("QuoteTXT made an invalid value %s: %v", quoted.Value, err)
+	}
+	if chunks, _ := splitTXT(quoted.Value); strings.Join(chunks, "") != longText+`"quoted"` {
+		t.Errorf("QuoteTXT value does not split back into the original text: %s", quoted.Value)
+	}
+	caa := Record{Type: RecordCAA, Value: "letsencrypt.org", CaaType: CAAIssue, IssuerCritical: CAACritical}
+	if err := caa.Validate(); err != nil {
+		t.Error(err)
+	}
+	hardLinked := Record{Type: RecordA, Value: "127.0.0.1", HardLink: true}
+	if err := hardLinked.Validate(); err != nil {
+		t.Errorf("HardLink should be ignored on records other than HTTPRED, got %v", err)
+	}
+
+	DMEClient, closeServer := newTestServerClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("invalid record was sent to %s", r.URL.Path)
+	}))
+	defer closeServer()
+	_, err := DMEClient.AddRecord(1, &Record{Type: RecordA, Value: "nope"})
+	if !errors.Is(err, ErrInvalidRecord) {
+		t.Errorf("expected ErrInvalidRecord from AddRecord, got %v", err)
+	}
+	err = DMEClient.UpdateRecords(1, []Record{{Type: RecordA, Value: "127.0.0.1"}, {Type: RecordMX, Value: "mail.example.org."}, {Type: RecordA}})
+	if !errors.Is(err, ErrInvalidRecord) {
+		t.Errorf("expected ErrInvalidRecord from UpdateRecords, got %v", err)
+	}
+}
+
+// TestRecordConstructors checks that the type-specific data put into each constructor comes back out of the matching accessor
+func TestRecordConstructors(t *testing.T) {
+	//The constructors should make the same records as the ones built by hand
+	testRecords := getTestRecords(false)
+	for _, thisCase := range []struct {
+		made     *Record
+		expected Record
+	}{
+		{NewARecord("testa", "127.8.4.3", 300), testRecords[0]},
+		{NewMXRecord("testmx", "example.org.", 10, 300), testRecords[4]},
+		{NewTXTRecord("testtxt", "originalvalue", 300), testRecords[6]},
+	} {
+		if !reflect.DeepEqual(*thisCase.made, thisCase.expected) {
+			t.Errorf("constructor made %+v, expected %+v", *thisCase.made, thisCase.expected)
+		}
+	}
+
+	mx, err := NewMXRecord("", "mail.example.org.", 10, 300).MX()
+	if err != nil || *mx != (MXData{Host: "mail.example.org.", Level: 10}) {
+		t.Errorf("unexpected MX data %+v (%v)", mx, err)
+	}
+
+	service := SRVData{Target: "sip.example.org.", Priority: 10, Weight: 20, Port: 5060}
+	srv, err := NewSRVRecord("_sip._tcp", service, 300).SRV()
+	if err != nil || *srv != service {
+		t.Errorf("unexpected SRV data %+v (%v)", srv, err)
+	}
+
+	authority := CAAData{Critical: true, Tag: CAAIssue, Value: "letsencrypt.org"}
+	caaRecord := NewCAARecord("", authority, 300)
+	caa, err := caaRecord.CAA()
+	if err != nil || *caa != authority || caaRecord.IssuerCritical != CAACritical {
+		t.Errorf("unexpected CAA data %+v (%v)", caa, err)
+	}
+
+	redirect := HTTPRedirect{URL: "https://example.org", RedirectType: RedirectPermanent, HardLink: true}
+	httpred, err := NewHTTPRedirect("www", redirect, 300).Redirect()
+	if err != nil || *httpred != redirect {
+		t.Errorf("unexpected redirect data %+v (%v)", httpred, err)
+	}
+
+	longText := strings.Repeat("v=DKIM1; ", 40) + `"quoted"`
+	text, err := NewTXTRecord("", longText, 300).Text()
+	if err != nil || text != longText {
+		t.Errorf("TXT text did not survive quoting: %q (%v)", text, err)
+	}
+
+	ip, err := NewAAAARecord("www", "::1", 300).IP()
+	if err != nil || !ip.Equal(net.IPv6loopback) {
+		t.Errorf("unexpected IP %v (%v)", ip, err)
+	}
+
+	_, err = NewARecord("www", "127.0.0.1", 300).MX()
+	if err == nil {
+		t.Error("expected an error getting MX data from an A record")
+	}
+}
+
+// TestTimestamps checks the conversion of DNS Made Easy's epoch timestamps, and that DomainsUpdatedSince filters and sorts by them
+func TestTimestamps(t *testing.T) {
+	thisDomain := Domain{Created: 1479254400000}
+	if !thisDomain.CreatedTime().Equal(time.Date(2016, time.November, 16, 0, 0, 0, 0, time.UTC)) || !thisDomain.UpdatedTime().IsZero() {
+		t.Errorf("unexpected times %v, %v", thisDomain.CreatedTime(), thisDomain.UpdatedTime())
+	}
+
+	DMEClient, closeServer := newTestServerClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"page": 0, "totalPages": 1, "data": [
+			{"id": 1, "name": "example.org", "updated": 1479328922220},
+			{"id": 2, "name": "example.net", "updated": 1479254400000},
+			{"id": 3, "name": "example.com", "updated": 1479300000000}
+		]}`))
+	}))
+	defer closeServer()
+
+	updatedDomains, err := DMEClient.DomainsUpdatedSince(time.Date(2016, time.November, 16, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updatedDomains) != 2 || updatedDomains[0].ID != 3 || updatedDomains[1].ID != 1 {
+		t.Errorf("unexpected updated domains %+v", updatedDomains)
+	}
+}
+
+// TestWaitForDomain checks that new domains are polled until their pending action clears, and that waiting gives up at the timeout
+func TestWaitForDomain(t *testing.T) {
+	var polls int
+	DMEClient, closeServer := newTestServerClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST":
+			w.Write([]byte(`{"id": 1, "name": "example.org", "pendingActionId": 7}`))
+		case r.URL.Path == "/dns/managed/1":
+			polls++
+			if polls < 3 {
+				w.Write([]byte(`{"id": 1, "name": "example.org", "pendingActionId": 7}`))
+				return
+			}
+			w.Write([]byte(`{"id": 1, "name": "example.org"}`))
+		case r.URL.Path == "/dns/secondary/2":
+			w.Write([]byte(`{"id": 2, "name": "example.net", "pendingActionId": 8}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer closeServer()
+	DMEClient.PendingActionPollInterval = time.Millisecond
+	DMEClient.CreateWaitTimeout = time.Second
+
+	newDomain, err := DMEClient.AddDomain(&Domain{Name: "example.org"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newDomain.PendingActionID != 0 || polls != 3 {
+		t.Errorf("expected a ready domain after 3 polls, got %+v after %v polls", newDomain, polls)
+	}
+
+	_, err = DMEClient.WaitForSecondaryDomain(2, 10*time.Millisecond)
+	if !IsPendingAction(err) {
+		t.Errorf("expected a pending action error, got %v", err)
+	}
+}
+
+// TestWriteZone checks the master file written for a domain with one of each type of record
+func TestWriteZone(t *testing.T) {
+	records := getTestRecords(false)
+	records = append(records, *NewCAARecord("", CAAData{Tag: CAAIssue, Value: "letsencrypt.org"}, 300))
+	gtdRecord := NewARecord("geo", "127.0.0.2", 300)
+	gtdRecord.GtdLocation = GTDEurope
+	records = append(records, *gtdRecord)
+
+	thisExport := &DomainExport{
+		Info: &Domain{
+			ID:          1,
+			Name:        "Example.org",
+			Updated:     1479328922220,
+			NameServers: []NameServer{{Fqdn: "ns10.dnsmadeeasy.com"}, {Fqdn: "ns11.dnsmadeeasy.com."}},
+		},
+		Records: &records,
+	}
+	zone := &strings.Builder{}
+	err := thisExport.WriteZone(zone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"$ORIGIN example.org.\n",
+		"@\t21600\tIN\tSOA\tns0.dnsmadeeasy.com. dns.dnsmadeeasy.com. (\n\t\t\t\t1479328922\t; serial\n",
+		"@\t21600\tIN\tNS\tns10.dnsmadeeasy.com.\n",
+		"@\t21600\tIN\tNS\tns11.dnsmadeeasy.com.\n",
+		"@\t300\tIN\tCAA\t0 issue \"letsencrypt.org\"\n",
+		"; ANAME (DNS Made Easy only, served as the A records of example.org.): @\t300\tIN\tANAME\texample.org.\n",
+		"_testsrv\t300\tIN\tSRV\t10 10 80 example.org.\n",
+		"; GTD location EUROPE (DNS Made Easy only): geo\t300\tIN\tA\t127.0.0.2\n",
+		"testa\t300\tIN\tA\t127.8.4.3\n",
+		"testmx\t300\tIN\tMX\t10 example.org.\n",
+		"; HTTPRED (DNS Made Easy HTTP redirect): testred -> http://example.org (STANDARD - 301)\n",
+		"testtxt\t300\tIN\tSPF\t\"originalvalue\"\n",
+		"testtxt\t300\tIN\tTXT\t\"originalvalue\"\n",
+	} {
+		if !strings.Contains(zone.String(), expected) {
+			t.Errorf("zone file is missing %q:\n%s", expected, zone.String())
+		}
+	}
+}
+
+// TestImportZoneFile checks that a zone file is parsed into DNS Made Easy records, and that a dry run doesn't create anything
+func TestImportZoneFile(t *testing.T) {
+	includeRoot := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(includeRoot, "mail.zone"), []byte("@ MX 10 mx1\n  MX 20 mx2.example.net.\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zone := `$ORIGIN example.org.
+$TTL 1h
+@	IN	SOA	ns1.example.org. hostmaster.example.org. (
+		2016111601 ; serial
+		3600 600 86400 300 )
+@		NS	ns1.example.org.
+@	300	IN	A	127.0.0.1
+www	IN	300	CNAME	@
+	TXT	"v=spf1 -all" ; same owner as the line above
+long	TXT	( "part one"
+		  "part \"two\"" )
+_sip._tcp	SRV	10 20 5060 sip
+@	CAA	128 issue "letsencrypt.org"
+sub	NS	ns1.example.net.
+$INCLUDE mail.zone sub.example.org.
+other.example.net.	A	127.0.0.2
+@	LOC	51 30 12.748 N 0 7 39.611 W 0.00m
+bad	A	not-an-ip
+`
+	var requests int
+	DMEClient, closeServer := newTestServerClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/dns/managed/1":
+			json.NewEncoder(w).Encode(Domain{ID: 1, Name: "Example.org"})
+		case "/dns/managed/1/records/createMulti":
+			var newRecords []Record
+			json.NewDecoder(r.Body).Decode(&newRecords)
+			json.NewEncoder(w).Encode(newRecords)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer closeServer()
+
+	thisImport, err := DMEClient.ImportZoneFile(1, strings.NewReader(zone), ZoneImportOptions{IncludeRoot: includeRoot, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 1 {
+		t.Errorf("dry run should only look up the domain, but made %v requests", requests)
+	}
+
+	expected := []Record{
+		*NewARecord("", "127.0.0.1", 300),
+		*NewCNAMERecord("www", "example.org.", 300),
+		*NewTXTRecord("www", "v=spf1 -all", 3600),
+		{Name: "long", Type: RecordTXT, Value: `"part one" "part \"two\""`, TTL: 3600, GtdLocation: GTDDefault},
+		*NewSRVRecord("_sip._tcp", SRVData{Target: "sip.example.org.", Priority: 10, Weight: 20, Port: 5060}, 3600),
+		*NewCAARecord("", CAAData{Critical: true, Tag: CAAIssue, Value: "letsencrypt.org"}, 3600),
+		*NewNSRecord("sub", "ns1.example.net.", 3600),
+		*NewMXRecord("sub", "mx1.sub.example.org.", 10, 3600),
+		*NewMXRecord("sub", "mx2.example.net.", 20, 3600),
+	}
+	if !reflect.DeepEqual(thisImport.Records, expected) {
+		t.Errorf("unexpected records:\n%+v\nexpected:\n%+v", thisImport.Records, expected)
+	}
+	var skippedLines []int
+	for _, skipped := range thisImport.Skipped {
+		skippedLines = append(skippedLines, skipped.Line)
+	}
+	if !reflect.DeepEqual(skippedLines, []int{3, 6, 16, 17, 18}) {
+		t.Errorf("unexpected skipped records %+v", thisImport.Skipped)
+	}
+
+	thisImport, err = DMEClient.ImportZoneFile(1, strings.NewReader(zone), ZoneImportOptions{Origin: "example.org", IncludeRoot: includeRoot})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 || len(thisImport.Records) != len(expected) {
+		t.Errorf("expected %v records created in 1 request, got %v records after %v requests", len(expected), len(thisImport.Records), requests-1)
+	}
+
+	for _, badZone := range []string{"$INCLUDE ../passwd\n", "www A ( 127.0.0.1\n", "www TXT \"unclosed\n", "$GENERATE 1-10 x A 127.0.0.$\n"} {
+		_, err := ParseZone(strings.NewReader(badZone), ZoneImportOptions{Origin: "example.org", IncludeRoot: includeRoot})
+		if err == nil {
+			t.Errorf("expected an error parsing %q", badZone)
+		}
+	}
+}
+
+// TestPlan checks that Diff matches records by ID, value and set, and that Apply makes the changes in order and refuses unsafe plans
+func TestPlan(t *testing.T) {
+	current := []Record{
+		{ID: 1, Name: "www", Type: RecordA, Value: "127.0.0.1", TTL: 300},
+		{ID: 2, Name: "www", Type: RecordA, Value: "127.0.0.2", TTL: 300},
+		{ID: 3, Name: "mail", Type: RecordMX, Value: "MX1.example.org.", MxLevel: 10, TTL: 300},
+		{ID: 4, Name: "txt", Type: RecordTXT, Value: `"hello"`, TTL: 300},
+		{ID: 5, Name: "api", Type: RecordCNAME, Value: "www", TTL: 300},
+		{ID: 6, Name: "old", Type: RecordA, Value: "127.0.0.9", TTL: 300},
+		{ID: 7, Name: "renamed", Type: RecordA, Value: "127.0.0.7", TTL: 300},
+		{ID: 8, Name: "ftp", Type: RecordA, Value: "127.0.0.8", TTL: 300, HardLink: true},
+	}
+	desired := []Record{
+		*NewARecord("www", "127.0.0.2", 300),
+		*NewARecord("WWW", "127.0.0.3", 300),
+		*NewMXRecord("mail", "mx1.example.org.", 10, 600),
+		*NewTXTRecord("txt", "hello", 300),
+		*NewCNAMERecord("api", "www2", 300),
+		*NewARecord("new", "127.0.0.4", 300),
+		*NewARecord("ftp", "127.0.0.8", 300),
+		{ID: 7, Name: "moved", Type: RecordA, Value: "127.0.0.7", TTL: 300},
+	}
+
+	plan := Diff(current, desired)
+	if plan.Unchanged != 3 || len(plan.Updates) != 4 || len(plan.Creates) != 1 || len(plan.Deletes) != 1 {
+		t.Fatalf("unexpected plan:\n%s", plan)
+	}
+	updatedIDs := map[int]string{}
+	for _, thisUpdate := range plan.Updates {
+		updatedIDs[thisUpdate.New.ID] = thisUpdate.New.Value
+	}
+	if !reflect.DeepEqual(updatedIDs, map[int]string{1: "127.0.0.3", 3: "mx1.example.org.", 5: "www2", 7: "127.0.0.7"}) {
+		t.Errorf("unexpected updates %v", updatedIDs)
+	}
+	if plan.Creates[0].Name != "new" || plan.Deletes[0].ID != 6 {
+		t.Errorf("unexpected creates %+v and deletes %+v", plan.Creates, plan.Deletes)
+	}
+	for _, expected := range []string{
+		"Plan: 1 to create, 4 to update, 1 to delete, 3 unchanged\n",
+		"- old\t300\tIN\tA\t127.0.0.9\n",
+		"~ mail\t600\tIN\tMX\t10 mx1.example.org. (ttl 300 -> 600)\n",
+		"~ moved\t300\tIN\tA\t127.0.0.7 (name \"renamed\" -> \"moved\")\n",
+		"+ new\t300\tIN\tA\t127.0.0.4\n",
+	} {
+		if !strings.Contains(plan.String(), expected) {
+			t.Errorf("plan is missing %q:\n%s", expected, plan)
+		}
+	}
+	if !Diff(current, current).Empty() {
+		t.Error("diff of the same records should be empty")
+	}
+
+	var requests []string
+	DMEClient, closeServer := newTestServerClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		if r.Method == "POST" {
+			io.Copy(w, r.Body)
+		}
+	}))
+	defer closeServer()
+
+	err := DMEClient.Apply(1, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedRequests := []string{
+		"DELETE /dns/managed/1/records?ids=6&",
+		"PUT /dns/managed/1/records/5",
+		"PUT /dns/managed/1/records/3",
+		"PUT /dns/managed/1/records/7",
+		"PUT /dns/managed/1/records/1",
+		"POST /dns/managed/1/records",
+	}
+	if !reflect.DeepEqual(requests, expectedRequests) {
+		t.Errorf("unexpected requests %v", requests)
+	}
+
+	requests = nil
+	err = DMEClient.Apply(1, Diff(current, desired[:2]))
+	if !errors.Is(err, ErrUnsafePlan) || len(requests) != 0 {
+		t.Errorf("expected ErrUnsafePlan without any requests, got %v after %v requests", err, len(requests))
+	}
+	DMEClient.MaxDeletePercent = NoDeletes
+	err = DMEClient.Apply(1, Diff(current, desired[1:]))
+	if !errors.Is(err, ErrUnsafePlan) || len(requests) != 0 {
+		t.Errorf("expected ErrUnsafePlan with deletes turned off, got %v after %v requests", err, len(requests))
+	}
+	err = DMEClient.Apply(1, Diff(current, append(current, *NewARecord("extra", "127.0.0.10", 300))))
+	if err != nil || !reflect.DeepEqual(requests, []string{"POST /dns/managed/1/records"}) {
+		t.Errorf("expected a plan without deletes to apply with deletes turned off, got %v after requests %v", err, requests)
+	}
+	requests = nil
+	DMEClient.MaxDeletePercent = 100
+	err = DMEClient.Apply(1, Diff(current, nil))
+	if err != nil || len(requests) != 1 {
+		t.Errorf("expected everything deleted in 1 request, got %v after %v requests", err, len(requests))
+	}
+}
+
+// TestZoneConfig checks that an export survives a round trip through a JSON config, and that names in a config are resolved to IDs
+func TestZoneConfig(t *testing.T) {
+	records := getTestRecords(false)
+	records = append(records, *NewCAARecord("", CAAData{Critical: true, Tag: CAAIssue, Value: "letsencrypt.org"}, 300))
+	records = append(records, *NewARecord("slow", "127.0.0.5", 86400))
+	gtdRecord := NewARecord("geo", "127.0.0.2", 300)
+	gtdRecord.GtdLocation = GTDEurope
+	records = append(records, *gtdRecord)
+	records = append(records, *NewHTTPRedirect("linkedred", HTTPRedirect{URL: "http://example.org", RedirectType: RedirectPermanent, HardLink: true}, 300))
+	linkedRecord := NewARecord("linked", "127.0.0.3", 300)
+	linkedRecord.HardLink = true
+	records = append(records, *linkedRecord)
+	for i := range records {
+		records[i].ID = i + 1
+	}
+
+	thisExport := AllDomainExport{
+		"example.org": {
+			Info:    &Domain{ID: 1, Name: "Example.org", GtdEnabled: true, SoaID: 10},
+			SOA:     &SOA{ID: 10, Name: "my soa"},
+			Records: &records,
+		},
+	}
+	thisConfig := NewZoneConfig(thisExport)
+	domainConfig := thisConfig.Domain("example.org.")
+	if domainConfig == nil || domainConfig.TTL != 300 || domainConfig.SOA != "my soa" || !domainConfig.GTD {
+		t.Fatalf("unexpected domain config %+v", domainConfig)
+	}
+	if slow := domainConfig.Records["slow"]; len(slow) != 1 || slow[0].TTL != 86400 || len(domainConfig.Records[ApexName]) != 2 {
+		t.Errorf("unexpected record configs %+v", domainConfig.Records)
+	}
+	if domainConfig.Records["linked"][0].HardLink || !domainConfig.Records["linkedred"][0].HardLink {
+		t.Errorf("HardLink should only be kept for HTTPRED records, got %+v and %+v", domainConfig.Records["linked"], domainConfig.Records["linkedred"])
+	}
+
+	configFile := &bytes.Buffer{}
+	err := thisConfig.WriteJSON(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(configFile.String(), "sourceId") || strings.Contains(configFile.String(), `"id"`) {
+		t.Errorf("config includes API fields:\n%s", configFile.String())
+	}
+	readConfig, err := ReadZoneConfigJSON(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readRecords, err := readConfig.Domains[0].RecordList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan := Diff(records, readRecords); !plan.Empty() || plan.Unchanged != len(records) {
+		t.Errorf("config doesn't match the export:\n%s", plan)
+	}
+	for _, thisRecord := range readRecords {
+		if thisRecord.HardLink != (thisRecord.Name == "linkedred") {
+			t.Errorf("unexpected HardLink on %s record %q after a round trip", thisRecord.Type, thisRecord.Name)
+		}
+	}
+
+	_, err = ReadZoneConfigJSON(strings.NewReader(`{"version": 2}`))
+	if err == nil {
+		t.Error("expected an error for an unknown version")
+	}
+	_, err = ReadZoneConfigJSON(strings.NewReader(`{"version": 1, "domains": [{"name": "example.org", "recrods": {}}]}`))
+	if err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+
+	DMEClient, closeServer := newTestServerClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/dns/soa":
+			w.Write([]byte(`{"page": 0, "totalPages": 1, "totalRecords": 1, "data": [{"id": 10, "name": "My SOA"}]}`))
+		case "/dns/vanity":
+			w.Write([]byte(`{"page": 0, "totalPages": 1, "totalRecords": 0, "data": []}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer closeServer()
+	thisDomain, err := DMEClient.DomainFromConfig(*domainConfig)
+	if err != nil || thisDomain.SoaID != 10 || thisDomain.Name != "example.org" || !thisDomain.GtdEnabled {
+		t.Errorf("unexpected domain %+v, error %v", thisDomain, err)
+	}
+	domainConfig.Vanity = "missing"
+	_, err = DMEClient.DomainFromConfig(*domainConfig)
+	if !IsNotFound(err) {
+		t.Errorf("expected a not found error for missing vanity name servers, got %v", err)
+	}
+}
+
+// TestBackupRestore checks that a backup of one account can be restored into another, with the IDs of everything remapped, and that
+// failover is set up again on the new records
+func TestBackupRestore(t *testing.T) {
+	sourceAccount := map[string]string{
+		"/dns/soa":               `[{"id": 1, "name": "custom"}]`,
+		"/dns/vanity":            `[{"id": 2, "name": "Public NS", "public": true}, {"id": 3, "name": "mine", "servers": ["ns1.example.net"]}]`,
+		"/dns/secondary/ipSet":   `[{"id": 4, "name": "set", "ips": ["127.0.0.1"]}]`,
+		"/dns/transferAcl":       `[{"id": 5, "name": "acl", "ips": ["127.0.0.2"]}]`,
+		"/dns/secondary":         `[{"id": 6, "name": "secondary.org", "ipSetId": 4, "folderId": 7}]`,
+		"/dns/managed":           `[{"id": 9, "name": "example.org", "soaId": 1, "vanityId": 3, "transferAclId": 5, "folderId": 8}]`,
+		"/dns/managed/9/records": `[{"id": 11, "name": "www", "type": "A", "value": "127.0.0.3", "ttl": 300, "sourceId": 9, "source": 1}, {"id": 13, "name": "", "type": "A", "value": "127.0.0.4", "ttl": 300, "monitor": true, "failover": true}]`,
+		"/monitor/13":            `{"recordId": 13, "monitor": true, "failover": true, "contactListId": 12, "ip1": "127.0.0.4", "ip2": "127.0.0.5", "protocolId": 2, "port": 80}`,
+		"/contactList":           `[{"id": 12, "name": "ops", "emails": ["ops@example.org"]}]`,
+		"/security/folder":       `[{"value": 7, "label": "Default"}, {"value": 8, "label": "Clients"}]`,
+		"/security/folder/7":     `{"id": 7, "name": "Default", "defaultFolder": true}`,
+		"/security/folder/8":     `{"id": 8, "name": "Clients", "domains": [9]}`,
+	}
+	targetAccount := map[string]string{
+		"/dns/vanity":      `[{"id": 202, "name": "public ns", "public": true}]`,
+		"/security/folder": `[{"value": 207, "label": "Default"}]`,
+	}
+
+	account := sourceAccount
+	nextID := 300
+	posted := map[string]map[string]interface{}{}
+	DMEClient, closeServer := newTestServerClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimSuffix(r.URL.Path, "/")
+		if r.Method == "POST" && strings.HasSuffix(path, "/createMulti") {
+			var newRecords []map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&newRecords)
+			posted[path] = newRecords[0]
+			var returnedRecords []map[string]interface{}
+			for i, thisRecord := range newRecords {
+				returnedRecord := map[string]interface{}{"id": 400 + i}
+				for k, v := range thisRecord {
+					if k != "id" {
+						returnedRecord[k] = v
+					}
+				}
+				returnedRecords = append(returnedRecords, returnedRecord)
+			}
+			json.NewEncoder(w).Encode(returnedRecords)
+			return
+		}
+		if r.Method == "PUT" {
+			newObject := map[string]interface{}{}
+			json.NewDecoder(r.Body).Decode(&newObject)
+			posted[path] = newObject
+			return
+		}
+		if r.Method == "POST" {
+			newObject := map[string]interface{}{}
+			json.NewDecoder(r.Body).Decode(&newObject)
+			posted[path] = newObject
+			newObject["id"] = nextID
+			nextID++
+			json.NewEncoder(w).Encode(newObject)
+			return
+		}
+		data, ok := account[path]
+		if !ok {
+			data = "[]"
+		}
+		if strings.HasPrefix(data, "[") && path != "/security/folder" {
+			fmt.Fprintf(w, `{"page": 0, "totalPages": 1, "totalRecords": 1, "data": %s}`, data)
+			return
+		}
+		w.Write([]byte(data))
+	}))
+	defer closeServer()
+
+	thisBackup, err := DMEClient.Backup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(thisBackup.Domains) != 1 || len(thisBackup.Domains[0].Records) != 2 || len(thisBackup.Folders) != 2 || len(thisBackup.SecondaryDomains) != 1 {
+		t.Fatalf("unexpected backup %+v", thisBackup)
+	}
+	if len(thisBackup.ContactLists) != 1 || len(thisBackup.Domains[0].Failover) != 1 || thisBackup.Domains[0].Failover[13].IP2 != "127.0.0.5" {
+		t.Fatalf("unexpected failover in backup %+v", thisBackup.Domains[0].Failover)
+	}
+	archive := &bytes.Buffer{}
+	err = thisBackup.Write(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	thisBackup, err = ReadBackup(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//A record from a backup without its failover config is restored with failover turned off
+	thisBackup.Domains[0].Records = append(thisBackup.Domains[0].Records, Record{ID: 14, Name: "old", Type: RecordA, Value: "127.0.0.6", TTL: 300, Monitor: true})
+
+	account = targetAccount
+	result, err := DMEClient.Restore(thisBackup, RestoreOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result.SkippedFailover, []string{"old.example.org A"}) {
+		t.Errorf("expected the failover of old.example.org to be skipped, got %v", result.SkippedFailover)
+	}
+	expectedIDs := map[string][]int{
+		"SOA":         {result.SOAIDs[1], 300},
+		"public NS":   {result.VanityIDs[2], 202},
+		"vanity":      {result.VanityIDs[3], 301},
+		"IP set":      {result.IPSetIDs[4], 302},
+		"ACL":         {result.TransferACLIDs[5], 303},
+		"default":     {result.FolderIDs[7], 207},
+		"folder":      {result.FolderIDs[8], 304},
+		"contacts":    {result.ContactListIDs[12], 305},
+		"domain":      {result.DomainIDs[9], 306},
+		"secondary":   {result.SecondaryDomainIDs[6], 307},
+		"domain SOA":  {int(posted["/dns/managed"]["soaId"].(float64)), 300},
+		"domain NS":   {int(posted["/dns/managed"]["vanityId"].(float64)), 301},
+		"domain ACL":  {int(posted["/dns/managed"]["transferAclId"].(float64)), 303},
+		"domain dir":  {int(posted["/dns/managed"]["folderId"].(float64)), 304},
+		"IP set used": {int(posted["/dns/secondary"]["ipSetId"].(float64)), 302},
+		"2ndary dir":  {int(posted["/dns/secondary"]["folderId"].(float64)), 207},
+		"record ID":   {int(posted["/dns/managed/306/records/createMulti"]["id"].(float64)), 0},
+		"record src":  {int(posted["/dns/managed/306/records/createMulti"]["sourceId"].(float64)), 0},
+		"failover":    {int(posted["/monitor/401"]["recordId"].(float64)), 401},
+		"contact ID":  {int(posted["/monitor/401"]["contactListId"].(float64)), 305},
+	}
+	for name, ids := range expectedIDs {
+		if ids[0] != ids[1] {
+			t.Errorf("%s: expected ID %v, got %v", name, ids[1], ids[0])
+		}
+	}
+	if _, ok := posted["/dns/vanity"]; ok && posted["/dns/vanity"]["name"] != "mine" {
+		t.Errorf("public vanity name servers should not be created, got %v", posted["/dns/vanity"])
+	}
+
+	if posted["/monitor/401"]["ip2"] != "127.0.0.5" || len(posted) != 10 {
+		t.Errorf("unexpected changes %v", posted)
+	}
+
+	posted = map[string]map[string]interface{}{}
+	targetAccount["/dns/managed"] = `[{"id": 306, "name": "Example.org"}]`
+	_, err = DMEClient.Restore(thisBackup, RestoreOptions{})
+	if !IsDuplicate(err) || len(posted) != 0 {
+		t.Errorf("expected a duplicate error without any changes, got %v after %v changes", err, len(posted))
+	}
+	result, err = DMEClient.Restore(thisBackup, RestoreOptions{SkipExistingDomains: true})
+	if err != nil || !reflect.DeepEqual(result.Skipped, []string{"example.org"}) {
+		t.Errorf("expected example.org to be skipped, got %v and error %v", result.Skipped, err)
+	}
+}
+
+// TestExportConcurrency checks that domains are exported a few at a time, that a failed domain doesn't stop the others, and that each
+// export has its own domain and SOA
+func TestExportConcurrency(t *testing.T) {
+	var inFlight, maxInFlight int32
+	DMEClient, closeServer := newTestServerClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/dns/managed/":
+			w.Write([]byte(`{"page": 0, "totalPages": 1, "totalRecords": 6, "data": [{"id": 1, "name": "a.org", "soaId": 10}, {"id": 2, "name": "b.org", "soaId": 20},
+				{"id": 3, "name": "c.org"}, {"id": 4, "name": "d.org"}, {"id": 5, "name": "e.org"}, {"id": 6, "name": "broken.org"}]}`))
+		case "/dns/soa":
+			w.Write([]byte(`{"page": 0, "totalPages": 1, "totalRecords": 2, "data": [{"id": 10, "name": "first"}, {"id": 20, "name": "second"}]}`))
+		case "/dns/managed/6/records":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			if !strings.HasSuffix(r.URL.Path, "/records") {
+				w.Write([]byte(`{"page": 0, "totalPages": 1, "totalRecords": 0, "data": []}`))
+				return
+			}
+			now := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			for {
+				seen := atomic.LoadInt32(&maxInFlight)
+				if now <= seen || atomic.CompareAndSwapInt32(&maxInFlight, seen, now) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			w.Write([]byte(`{"page": 0, "totalPages": 1, "totalRecords": 1, "data": [{"id": 100, "name": "www", "type": "A", "value": "127.0.0.1"}]}`))
+		}
+	}))
+	defer closeServer()
+
+	var progress []int
+	allDomains, err := DMEClient.ExportAllDomainsWithOptions(ExportOptions{
+		Concurrency: 3,
+		Progress: func(Done, Total int, DomainName string) {
+			if Total != 6 {
+				t.Errorf("expected a total of 6 domains, got %v", Total)
+			}
+			progress = append(progress, Done)
+		},
+	})
+
+	var exportError *ExportError
+	if !errors.As(err, &exportError) || len(exportError.Domains) != 1 || exportError.Domains["broken.org"] == nil {
+		t.Fatalf("expected an *ExportError for broken.org, got %v", err)
+	}
+	if allDomains == nil || len(*allDomains) != 5 {
+		t.Fatalf("expected the other 5 domains to be exported, got %v", allDomains)
+	}
+	if !reflect.DeepEqual(progress, []int{1, 2, 3, 4, 5, 6}) {
+		t.Errorf("unexpected progress %v", progress)
+	}
+	if maxInFlight < 2 || maxInFlight > 3 {
+		t.Errorf("expected up to 3 domains at once, got %v", maxInFlight)
+	}
+	for domainName, thisExport := range *allDomains {
+		if thisExport.Info.Name != domainName || len(*thisExport.Records) != 1 {
+			t.Errorf("export of %s has the info of %s and %v records", domainName, thisExport.Info.Name, len(*thisExport.Records))
+		}
+	}
+	if (*allDomains)["a.org"].SOA.Name != "first" || (*allDomains)["b.org"].SOA.Name != "second" || (*allDomains)["c.org"].SOA != nil {
+		t.Errorf("domains have the wrong SOA")
+	}
+}
+
 //Create a test domain, return the domain entry for this domain, and add it to our list of domains that needs to be cleaned up at the end
 //Names are generated using a timestamp.
 func generateTestDomain(DMEClient *GoDMEConfig) (*Domain, error) {
@@ -416,6 +1840,21 @@ func newClient() (*GoDMEConfig, error) {
 
 }
 
+//Create a DNS Made Easy client that talks to a local test server instead of the sandbox, for tests that don't need the real API
+func newTestServerClient(t *testing.T, handler http.Handler) (*GoDMEConfig, func()) {
+	server := httptest.NewServer(handler)
+	DMEClient, err := NewGoDNSMadeEasy(&GoDMEConfig{
+		APIKey:    "testkey",
+		SecretKey: "testsecret",
+		APIUrl:    server.URL,
+	})
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	return DMEClient, server.Close
+}
+
 func getTestRecords(Updated bool) []Record {
 	recIPVal, recTTL, recIPv6Val, recDomain, recData := "127.8.4.3", 300, "::1", "example.org.", "\"originalvalue\""
 
@@ -2,33 +2,67 @@ package GoDNSMadeEasy
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 )
 
 // Domains returns the list of domains managed by DNS Made Easy
 func (dme *GoDMEConfig) Domains() ([]Domain, error) {
-	req, err := dme.newRequest("GET", "dns/managed/", nil)
-	if err != nil {
-		return nil, err
-	}
+	return dme.DomainsContext(context.Background())
+}
 
-	genericResponse := &GenericResponse{}
-	err = dme.doDMERequest(req, &genericResponse)
+// DomainsContext is the same as Domains(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) DomainsContext(ctx context.Context) ([]Domain, error) {
+	domainData := []Domain{}
+	err := dme.EachDomainContext(ctx, func(thisDomain Domain) error {
+		domainData = append(domainData, thisDomain)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	return domainData, nil
+}
 
-	domainData := []Domain{}
-	err = json.Unmarshal(genericResponse.Data, &domainData)
-	return domainData, err
+// EachDomain calls fn for every domain managed by DNS Made Easy, fetching them one page at a time so that they don't all need to be held in
+// memory. If fn returns an error, no more domains are fetched and that error is returned.
+func (dme *GoDMEConfig) EachDomain(fn func(Domain) error) error {
+	return dme.EachDomainContext(context.Background(), fn)
+}
+
+// EachDomainContext is the same as EachDomain(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) EachDomainContext(ctx context.Context, fn func(Domain) error) error {
+	return dme.eachPage(ctx, "dns/managed/", func(pageData json.RawMessage) error {
+		domainData := []Domain{}
+		err := json.Unmarshal(pageData, &domainData)
+		if err != nil {
+			return err
+		}
+		for _, thisDomain := range domainData {
+			err = fn(thisDomain)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // Domain returns the summary data for a single domain. This is essentially the same as Domains(), but only returns one domain.
 func (dme *GoDMEConfig) Domain(DomainID int) (*Domain, error) {
+	return dme.DomainContext(context.Background(), DomainID)
+}
+
+// DomainContext is the same as Domain(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) DomainContext(ctx context.Context, DomainID int) (*Domain, error) {
 	reqStub := fmt.Sprintf("dns/managed/%v", DomainID)
-	req, err := dme.newRequest("GET", reqStub, nil)
+	req, err := dme.newRequest(ctx, "GET", reqStub, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -42,110 +76,355 @@ func (dme *GoDMEConfig) Domain(DomainID int) (*Domain, error) {
 	return domainResponse, nil
 }
 
-// Records returns the records for a given domain. The domain is specified by its ID, which can be retrieved from Domains()
-func (dme *GoDMEConfig) Records(DomainID int) ([]Record, error) {
-	reqStub := fmt.Sprintf("dns/managed/%v/records", DomainID)
-	req, err := dme.newRequest("GET", reqStub, nil)
+// DomainByName returns the summary data for a single domain, looked up by its name rather than its ID. The name is not case sensitive, and
+// may have a trailing dot. If there is no such domain, the error is a *NotFoundError.
+func (dme *GoDMEConfig) DomainByName(Name string) (*Domain, error) {
+	return dme.DomainByNameContext(context.Background(), Name)
+}
+
+// DomainByNameContext is the same as DomainByName(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) DomainByNameContext(ctx context.Context, Name string) (*Domain, error) {
+	domainName := normaliseDomainName(Name)
+	reqStub := "dns/managed/name?domainname=" + url.QueryEscape(domainName)
+	req, err := dme.newRequest(ctx, "GET", reqStub, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	genericResponse := &GenericResponse{}
-	err = dme.doDMERequest(req, &genericResponse)
+	domainResponse := &Domain{}
+	err = dme.doDMERequest(req, domainResponse)
+	if IsNotFound(err) || (err == nil && domainResponse.ID == 0) {
+		//DNS Made Easy sends back either a 404 or an empty domain, depending on its mood
+		return nil, &NotFoundError{Kind: "domain", Name: domainName}
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	return domainResponse, nil
+}
+
+// Records returns the records for a given domain. The domain is specified by its ID, which can be retrieved from Domains()
+func (dme *GoDMEConfig) Records(DomainID int) ([]Record, error) {
+	return dme.RecordsContext(context.Background(), DomainID)
+}
+
+// RecordsContext is the same as Records(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) RecordsContext(ctx context.Context, DomainID int) ([]Record, error) {
 	recordData := []Record{}
-	err = json.Unmarshal(genericResponse.Data, &recordData)
-	return recordData, err
+	err := dme.EachRecordContext(ctx, DomainID, func(thisRecord Record) error {
+		recordData = append(recordData, thisRecord)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recordData, nil
+}
 
+// EachRecord calls fn for every record in a given domain, fetching them one page at a time so that very large domains don't need to be
+// held in memory. If fn returns an error, no more records are fetched and that error is returned.
+func (dme *GoDMEConfig) EachRecord(DomainID int, fn func(Record) error) error {
+	return dme.EachRecordContext(context.Background(), DomainID, fn)
 }
 
-// Record returns the record for a given record ID. This is essentially the same as Records(), but only returns one record
-func (dme *GoDMEConfig) Record(DomainID, RecordID int) (*Record, error) {
-	return nil, fmt.Errorf("Record() Not yet implemented")
+// EachRecordContext is the same as EachRecord(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) EachRecordContext(ctx context.Context, DomainID int, fn func(Record) error) error {
+	reqStub := fmt.Sprintf("dns/managed/%v/records", DomainID)
+	return dme.eachRecordPage(ctx, reqStub, fn)
 }
 
-// SOA returns custom Start of Authority records for an account.
-func (dme *GoDMEConfig) SOA() ([]SOA, error) {
-	req, err := dme.newRequest("GET", "dns/soa", nil)
+// RecordFilter narrows down the records returned by RecordsFilter(). Fields that are left empty are not filtered on.
+type RecordFilter struct {
+	// Name is the exact record name to match (e.g. "www" or "_acme-challenge"). DNS Made Easy does not support wildcards here.
+	Name string
+	// Type is the record type to match (e.g. RecordTXT)
+	Type RecordType
+}
+
+// RecordsFilter returns the records for a given domain that match the filter. The filtering is done by DNS Made Easy, so only the matching
+// records are downloaded.
+func (dme *GoDMEConfig) RecordsFilter(DomainID int, Filter RecordFilter) ([]Record, error) {
+	return dme.RecordsFilterContext(context.Background(), DomainID, Filter)
+}
+
+// RecordsFilterContext is the same as RecordsFilter(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) RecordsFilterContext(ctx context.Context, DomainID int, Filter RecordFilter) ([]Record, error) {
+	recordData := []Record{}
+	err := dme.eachRecordPage(ctx, recordsEndpoint(DomainID, Filter), func(thisRecord Record) error {
+		recordData = append(recordData, thisRecord)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	return recordData, nil
+}
 
-	genericResponse := &GenericResponse{}
-	err = dme.doDMERequest(req, &genericResponse)
-	if err != nil {
+// recordsEndpoint is the endpoint listing the records of a domain, filtered by DNS Made Easy if the filter has anything in it
+func recordsEndpoint(DomainID int, Filter RecordFilter) string {
+	query := url.Values{}
+	if Filter.Name != "" {
+		query.Set("recordName", Filter.Name)
+	}
+	if Filter.Type != "" {
+		query.Set("type", string(Filter.Type))
+	}
+	reqStub := fmt.Sprintf("dns/managed/%v/records", DomainID)
+	if len(query) > 0 {
+		reqStub += "?" + query.Encode()
+	}
+	return reqStub
+}
+
+// All of the record listing endpoints return the same pages of records, so this decodes each page and hands the records to fn one at a time
+func (dme *GoDMEConfig) eachRecordPage(ctx context.Context, Endpoint string, fn func(Record) error) error {
+	return dme.eachPage(ctx, Endpoint, func(pageData json.RawMessage) error {
+		recordData := []Record{}
+		err := json.Unmarshal(pageData, &recordData)
+		if err != nil {
+			return err
+		}
+		for _, thisRecord := range recordData {
+			err = fn(thisRecord)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Record returns the record for a given record ID. DNS Made Easy has no API for fetching a single record, so this looks through the domain's
+// records a page at a time, and stops as soon as it finds the record. If the record does not exist, the error matches IsNotFound().
+//
+// Each page is a separate request (of PageSize records) that counts against the rate limit, so finding a record near the end of a large
+// domain costs as much as fetching all of its records. If you know the record's name or type, use RecordFiltered() instead.
+func (dme *GoDMEConfig) Record(DomainID, RecordID int) (*Record, error) {
+	return dme.RecordContext(context.Background(), DomainID, RecordID)
+}
+
+// RecordContext is the same as Record(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) RecordContext(ctx context.Context, DomainID, RecordID int) (*Record, error) {
+	return dme.RecordFilteredContext(ctx, DomainID, RecordID, RecordFilter{})
+}
+
+// RecordFiltered is the same as Record(), but only looks through the records that match the filter (see RecordsFilter). DNS Made Easy
+// does the filtering, so this usually takes a single request however big the domain is.
+func (dme *GoDMEConfig) RecordFiltered(DomainID, RecordID int, Filter RecordFilter) (*Record, error) {
+	return dme.RecordFilteredContext(context.Background(), DomainID, RecordID, Filter)
+}
+
+// RecordFilteredContext is the same as RecordFiltered(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) RecordFilteredContext(ctx context.Context, DomainID, RecordID int, Filter RecordFilter) (*Record, error) {
+	var foundRecord *Record
+	errFound := errors.New("found record")
+	err := dme.eachRecordPage(ctx, recordsEndpoint(DomainID, Filter), func(thisRecord Record) error {
+		if thisRecord.ID != RecordID {
+			return nil
+		}
+		foundRecord = &thisRecord
+		return errFound
+	})
+	if err != nil && err != errFound {
 		return nil, err
 	}
+	if foundRecord == nil {
+		return nil, fmt.Errorf("record %v does not exist in domain %v: %w", RecordID, DomainID, ErrNotFound)
+	}
+	return foundRecord, nil
+}
+
+// SOA returns custom Start of Authority records for an account.
+func (dme *GoDMEConfig) SOA() ([]SOA, error) {
+	return dme.SOAContext(context.Background())
+}
 
+// SOAContext is the same as SOA(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) SOAContext(ctx context.Context) ([]SOA, error) {
 	soaData := []SOA{}
-	json.Unmarshal(genericResponse.Data, &soaData)
+	err := dme.eachPage(ctx, "dns/soa", func(pageData json.RawMessage) error {
+		pageSOA := []SOA{}
+		err := json.Unmarshal(pageData, &pageSOA)
+		soaData = append(soaData, pageSOA...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	return soaData, nil
 }
 
 // Vanity returns custom Vanity name servers for an account
 func (dme *GoDMEConfig) Vanity() ([]Vanity, error) {
-	req, err := dme.newRequest("GET", "dns/vanity", nil)
-	if err != nil {
-		return nil, err
-	}
+	return dme.VanityContext(context.Background())
+}
 
-	vanityResponse := &GenericResponse{}
-	err = dme.doDMERequest(req, &vanityResponse)
+// VanityContext is the same as Vanity(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) VanityContext(ctx context.Context) ([]Vanity, error) {
+	vanityData := []Vanity{}
+	err := dme.eachPage(ctx, "dns/vanity", func(pageData json.RawMessage) error {
+		pageVanity := []Vanity{}
+		err := json.Unmarshal(pageData, &pageVanity)
+		vanityData = append(vanityData, pageVanity...)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	vanityData := []Vanity{}
-	json.Unmarshal(vanityResponse.Data, &vanityData)
-
 	return vanityData, nil
 }
 
 // IPSets returns custom IPSets for an account, used for secondary DNS
 func (dme *GoDMEConfig) IPSets() ([]IPSet, error) {
-	req, err := dme.newRequest("GET", "dns/secondary/ipSet", nil)
+	return dme.IPSetsContext(context.Background())
+}
+
+// IPSetsContext is the same as IPSets(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) IPSetsContext(ctx context.Context) ([]IPSet, error) {
+	ipSetData := []IPSet{}
+	err := dme.eachPage(ctx, "dns/secondary/ipSet", func(pageData json.RawMessage) error {
+		pageIPSets := []IPSet{}
+		err := json.Unmarshal(pageData, &pageIPSets)
+		ipSetData = append(ipSetData, pageIPSets...)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	genericResponse := &GenericResponse{}
-	err = dme.doDMERequest(req, &genericResponse)
+	return ipSetData, nil
+}
+
+// TransferACLs returns the transfer ACLs for an account. These list the servers that are allowed to do zone transfers (AXFR) of a domain.
+func (dme *GoDMEConfig) TransferACLs() ([]TransferACL, error) {
+	return dme.TransferACLsContext(context.Background())
+}
+
+// TransferACLsContext is the same as TransferACLs(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) TransferACLsContext(ctx context.Context) ([]TransferACL, error) {
+	aclData := []TransferACL{}
+	err := dme.eachPage(ctx, "dns/transferAcl", func(pageData json.RawMessage) error {
+		pageACLs := []TransferACL{}
+		err := json.Unmarshal(pageData, &pageACLs)
+		aclData = append(aclData, pageACLs...)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	ipSetData := []IPSet{}
-	json.Unmarshal(genericResponse.Data, &ipSetData)
-
-	return ipSetData, nil
+	return aclData, nil
 }
 
 // SecondaryDomains returns the list of secondary domains belonging to an account
 func (dme *GoDMEConfig) SecondaryDomains() ([]SecondaryDomain, error) {
-	req, err := dme.newRequest("GET", "dns/secondary", nil)
+	return dme.SecondaryDomainsContext(context.Background())
+}
+
+// SecondaryDomainsContext is the same as SecondaryDomains(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) SecondaryDomainsContext(ctx context.Context) ([]SecondaryDomain, error) {
+	secondaryDomains := []SecondaryDomain{}
+	err := dme.EachSecondaryDomainContext(ctx, func(thisSecondaryDomain SecondaryDomain) error {
+		secondaryDomains = append(secondaryDomains, thisSecondaryDomain)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	genericResponse := &GenericResponse{}
-	err = dme.doDMERequest(req, &genericResponse)
+	return secondaryDomains, nil
+}
+
+// EachSecondaryDomain calls fn for every secondary domain belonging to an account, fetching them one page at a time. If fn returns an error,
+// no more secondary domains are fetched and that error is returned.
+func (dme *GoDMEConfig) EachSecondaryDomain(fn func(SecondaryDomain) error) error {
+	return dme.EachSecondaryDomainContext(context.Background(), fn)
+}
+
+// EachSecondaryDomainContext is the same as EachSecondaryDomain(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) EachSecondaryDomainContext(ctx context.Context, fn func(SecondaryDomain) error) error {
+	return dme.eachPage(ctx, "dns/secondary", func(pageData json.RawMessage) error {
+		secondaryDomains := []SecondaryDomain{}
+		err := json.Unmarshal(pageData, &secondaryDomains)
+		if err != nil {
+			return err
+		}
+		for _, thisSecondaryDomain := range secondaryDomains {
+			err = fn(thisSecondaryDomain)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SecondaryDomain returns a single secondary domain (identified by its ID)
+func (dme *GoDMEConfig) SecondaryDomain(SecondaryDomainID int) (*SecondaryDomain, error) {
+	return dme.SecondaryDomainContext(context.Background(), SecondaryDomainID)
+}
+
+// SecondaryDomainContext is the same as SecondaryDomain(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) SecondaryDomainContext(ctx context.Context, SecondaryDomainID int) (*SecondaryDomain, error) {
+	reqStub := fmt.Sprintf("dns/secondary/%v", SecondaryDomainID)
+	req, err := dme.newRequest(ctx, "GET", reqStub, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	secondaryDomains := []SecondaryDomain{}
-	json.Unmarshal(genericResponse.Data, &secondaryDomains)
+	secondaryDomainResponse := &SecondaryDomain{}
+	err = dme.doDMERequest(req, secondaryDomainResponse)
+	if err != nil {
+		return nil, err
+	}
 
-	return secondaryDomains, nil
+	return secondaryDomainResponse, nil
+}
+
+// SecondaryDomainByName returns a single secondary domain, looked up by its name rather than its ID. The name is not case sensitive, and
+// may have a trailing dot. If there is no such secondary domain, the error is a *NotFoundError.
+func (dme *GoDMEConfig) SecondaryDomainByName(Name string) (*SecondaryDomain, error) {
+	return dme.SecondaryDomainByNameContext(context.Background(), Name)
+}
+
+// SecondaryDomainByNameContext is the same as SecondaryDomainByName(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) SecondaryDomainByNameContext(ctx context.Context, Name string) (*SecondaryDomain, error) {
+	//There is no lookup by name for secondary domains, so look through them a page at a time and stop once we find it
+	domainName := normaliseDomainName(Name)
+	var foundDomain *SecondaryDomain
+	errFound := errors.New("found secondary domain")
+	err := dme.EachSecondaryDomainContext(ctx, func(thisSecondaryDomain SecondaryDomain) error {
+		if normaliseDomainName(thisSecondaryDomain.Name) != domainName {
+			return nil
+		}
+		foundDomain = &thisSecondaryDomain
+		return errFound
+	})
+	if err != nil && err != errFound {
+		return nil, err
+	}
+	if foundDomain == nil {
+		return nil, &NotFoundError{Kind: "secondary domain", Name: domainName}
+	}
+	return foundDomain, nil
+}
+
+// Domain names are case insensitive, and may or may not be written fully qualified with a trailing dot
+func normaliseDomainName(Name string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(Name), "."))
 }
 
 // Folders returns the list of folders belonging to an account
 func (dme *GoDMEConfig) Folders() ([]Folder, error) {
-	req, err := dme.newRequest("GET", "security/folder", nil)
+	return dme.FoldersContext(context.Background())
+}
+
+// FoldersContext is the same as Folders(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) FoldersContext(ctx context.Context) ([]Folder, error) {
+	req, err := dme.newRequest(ctx, "GET", "security/folder", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -159,15 +438,51 @@ func (dme *GoDMEConfig) Folders() ([]Folder, error) {
 	return folderList, nil
 }
 
-// AddRecord adds a DNS record to a given domain (identified by its ID)
+// Folder returns the detailed information for a single folder (identified by its ID), including the domains in it and its permissions
+func (dme *GoDMEConfig) Folder(FolderID int) (*FolderDetail, error) {
+	return dme.FolderContext(context.Background(), FolderID)
+}
+
+// FolderContext is the same as Folder(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) FolderContext(ctx context.Context, FolderID int) (*FolderDetail, error) {
+	reqStub := fmt.Sprintf("security/folder/%v", FolderID)
+	req, err := dme.newRequest(ctx, "GET", reqStub, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	folderResponse := &FolderDetail{}
+	err = dme.doDMERequest(req, folderResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return folderResponse, nil
+}
+
+// AddRecord adds a DNS record to a given domain (identified by its ID). The record is checked with Record.Validate() first. If the record
+// has a GTD location other than GTDDefault, the domain must have GTD enabled, otherwise the error matches ErrGTDNotEnabled.
 func (dme *GoDMEConfig) AddRecord(DomainID int, RecordRecord *Record) (*Record, error) {
+	return dme.AddRecordContext(context.Background(), DomainID, RecordRecord)
+}
+
+// AddRecordContext is the same as AddRecord(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) AddRecordContext(ctx context.Context, DomainID int, RecordRecord *Record) (*Record, error) {
+	err := RecordRecord.Validate()
+	if err != nil {
+		return nil, err
+	}
+	err = dme.checkGTDLocations(ctx, DomainID, *RecordRecord)
+	if err != nil {
+		return nil, err
+	}
 	reqStub := fmt.Sprintf("dns/managed/%v/records", DomainID)
 	bodyData, err := json.Marshal(RecordRecord)
 	if err != nil {
 		return nil, err
 	}
 	bodyBuffer := bytes.NewReader(bodyData)
-	req, err := dme.newRequest("POST", reqStub, bodyBuffer)
+	req, err := dme.newRequest(ctx, "POST", reqStub, bodyBuffer)
 	if err != nil {
 		return nil, err
 	}
@@ -181,15 +496,61 @@ func (dme *GoDMEConfig) AddRecord(DomainID int, RecordRecord *Record) (*Record,
 	return returnedRecord, err
 }
 
-// AddDomain adds a domain to your DNS Made Easy account
+// AddRecords adds many DNS records to a given domain (identified by its ID) using DNS Made Easy's bulk create endpoint. Large batches are
+// split into chunks of BulkChunkSize records. If any chunks fail, the records from the successful chunks are still returned, along with
+// a *BulkError describing each failed chunk.
+func (dme *GoDMEConfig) AddRecords(DomainID int, Records []Record) ([]Record, error) {
+	return dme.AddRecordsContext(context.Background(), DomainID, Records)
+}
+
+// AddRecordsContext is the same as AddRecords(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) AddRecordsContext(ctx context.Context, DomainID int, Records []Record) ([]Record, error) {
+	err := validateRecords(Records)
+	if err != nil {
+		return nil, err
+	}
+	err = dme.checkGTDLocations(ctx, DomainID, Records...)
+	if err != nil {
+		return nil, err
+	}
+	reqStub := fmt.Sprintf("dns/managed/%v/records/createMulti", DomainID)
+	returnedRecords := []Record{}
+	err = dme.eachChunk(ctx, Records, func(chunk []Record) error {
+		bodyData, err := json.Marshal(chunk)
+		if err != nil {
+			return err
+		}
+		req, err := dme.newRequest(ctx, "POST", reqStub, bytes.NewReader(bodyData))
+		if err != nil {
+			return err
+		}
+
+		chunkRecords := []Record{}
+		err = dme.doDMERequest(req, &chunkRecords)
+		if err != nil {
+			return err
+		}
+		returnedRecords = append(returnedRecords, chunkRecords...)
+		return nil
+	})
+	return returnedRecords, err
+}
+
+// AddDomain adds a domain to your DNS Made Easy account. If CreateWaitTimeout is set, this waits for the domain to be ready (see WaitForDomain)
+// before returning it.
 func (dme *GoDMEConfig) AddDomain(DomainRecord *Domain) (*Domain, error) {
+	return dme.AddDomainContext(context.Background(), DomainRecord)
+}
+
+// AddDomainContext is the same as AddDomain(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) AddDomainContext(ctx context.Context, DomainRecord *Domain) (*Domain, error) {
 	reqStub := "dns/managed/"
 	bodyData, err := json.Marshal(DomainRecord)
 	if err != nil {
 		return nil, err
 	}
 	bodyBuffer := bytes.NewReader(bodyData)
-	req, err := dme.newRequest("POST", reqStub, bodyBuffer)
+	req, err := dme.newRequest(ctx, "POST", reqStub, bodyBuffer)
 	if err != nil {
 
 		return nil, err
@@ -201,17 +562,28 @@ func (dme *GoDMEConfig) AddDomain(DomainRecord *Domain) (*Domain, error) {
 		return nil, err
 	}
 
+	if dme.CreateWaitTimeout > 0 && returnedDomain.PendingActionID != 0 {
+		return dme.WaitForDomainContext(ctx, returnedDomain.ID, dme.CreateWaitTimeout)
+	}
 	return returnedDomain, err
 }
 
 // AddVanity creates a custom set of Vanity nameservers for an account. These can then be assigned to domains.
 func (dme *GoDMEConfig) AddVanity(newVanity Vanity) (*Vanity, error) {
+	return dme.AddVanityContext(context.Background(), newVanity)
+}
+
+// AddVanityContext is the same as AddVanity(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) AddVanityContext(ctx context.Context, newVanity Vanity) (*Vanity, error) {
 	bodyData, err := json.Marshal(newVanity)
 	if err != nil {
 		return nil, err
 	}
 	bodyBuffer := bytes.NewReader(bodyData)
-	req, err := dme.newRequest("POST", "dns/vanity", bodyBuffer)
+	req, err := dme.newRequest(ctx, "POST", "dns/vanity", bodyBuffer)
+	if err != nil {
+		return nil, err
+	}
 
 	returnedVanity := &Vanity{}
 	err = dme.doDMERequest(req, returnedVanity)
@@ -223,12 +595,20 @@ func (dme *GoDMEConfig) AddVanity(newVanity Vanity) (*Vanity, error) {
 
 // AddSOA creates a custom SOA record for an account. These can then be assigned to domains.
 func (dme *GoDMEConfig) AddSOA(newSOA SOA) (*SOA, error) {
+	return dme.AddSOAContext(context.Background(), newSOA)
+}
+
+// AddSOAContext is the same as AddSOA(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) AddSOAContext(ctx context.Context, newSOA SOA) (*SOA, error) {
 	bodyData, err := json.Marshal(newSOA)
 	if err != nil {
 		return nil, err
 	}
 	bodyBuffer := bytes.NewReader(bodyData)
-	req, err := dme.newRequest("POST", "dns/soa", bodyBuffer)
+	req, err := dme.newRequest(ctx, "POST", "dns/soa", bodyBuffer)
+	if err != nil {
+		return nil, err
+	}
 
 	returnedSOA := &SOA{}
 	err = dme.doDMERequest(req, returnedSOA)
@@ -240,12 +620,20 @@ func (dme *GoDMEConfig) AddSOA(newSOA SOA) (*SOA, error) {
 
 // AddIPSet creates a custom IPSet record for an account. These can then be assigned to secondary domains.
 func (dme *GoDMEConfig) AddIPSet(newIPSet IPSet) (*IPSet, error) {
+	return dme.AddIPSetContext(context.Background(), newIPSet)
+}
+
+// AddIPSetContext is the same as AddIPSet(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) AddIPSetContext(ctx context.Context, newIPSet IPSet) (*IPSet, error) {
 	bodyData, err := json.Marshal(newIPSet)
 	if err != nil {
 		return nil, err
 	}
 	bodyBuffer := bytes.NewReader(bodyData)
-	req, err := dme.newRequest("POST", "dns/secondary/ipSet", bodyBuffer)
+	req, err := dme.newRequest(ctx, "POST", "dns/secondary/ipSet", bodyBuffer)
+	if err != nil {
+		return nil, err
+	}
 
 	returnedIPSet := &IPSet{}
 	err = dme.doDMERequest(req, returnedIPSet)
@@ -255,97 +643,377 @@ func (dme *GoDMEConfig) AddIPSet(newIPSet IPSet) (*IPSet, error) {
 	return returnedIPSet, err
 }
 
-// AddSecondaryDomain adds a secondary domain to your account
+// AddTransferACL creates a transfer ACL for an account. These can then be assigned to domains.
+func (dme *GoDMEConfig) AddTransferACL(newTransferACL TransferACL) (*TransferACL, error) {
+	return dme.AddTransferACLContext(context.Background(), newTransferACL)
+}
+
+// AddTransferACLContext is the same as AddTransferACL(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) AddTransferACLContext(ctx context.Context, newTransferACL TransferACL) (*TransferACL, error) {
+	bodyData, err := json.Marshal(newTransferACL)
+	if err != nil {
+		return nil, err
+	}
+	bodyBuffer := bytes.NewReader(bodyData)
+	req, err := dme.newRequest(ctx, "POST", "dns/transferAcl", bodyBuffer)
+	if err != nil {
+		return nil, err
+	}
+
+	returnedTransferACL := &TransferACL{}
+	err = dme.doDMERequest(req, returnedTransferACL)
+	if err != nil {
+		return nil, err
+	}
+	return returnedTransferACL, err
+}
+
+// AddSecondaryDomain adds a secondary domain to your account. If CreateWaitTimeout is set, this waits for the secondary domain to be ready
+// (see WaitForSecondaryDomain) before returning it.
 func (dme *GoDMEConfig) AddSecondaryDomain(newSecondaryDomain SecondaryDomain) (*SecondaryDomain, error) {
+	return dme.AddSecondaryDomainContext(context.Background(), newSecondaryDomain)
+}
+
+// AddSecondaryDomainContext is the same as AddSecondaryDomain(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) AddSecondaryDomainContext(ctx context.Context, newSecondaryDomain SecondaryDomain) (*SecondaryDomain, error) {
 	bodyData, err := json.Marshal(newSecondaryDomain)
 	if err != nil {
 		return nil, err
 	}
 	bodyBuffer := bytes.NewReader(bodyData)
-	req, err := dme.newRequest("POST", "dns/secondary", bodyBuffer)
+	req, err := dme.newRequest(ctx, "POST", "dns/secondary", bodyBuffer)
+	if err != nil {
+		return nil, err
+	}
 
 	returnedSecondaryDomain := &SecondaryDomain{}
 	err = dme.doDMERequest(req, returnedSecondaryDomain)
 	if err != nil {
 		return nil, err
 	}
+	if dme.CreateWaitTimeout > 0 && returnedSecondaryDomain.PendingActionID != 0 {
+		return dme.WaitForSecondaryDomainContext(ctx, returnedSecondaryDomain.ID, dme.CreateWaitTimeout)
+	}
 	return returnedSecondaryDomain, err
 }
 
+// AddFolder creates a folder for an account. Domains and secondary domains can then be moved into it.
+func (dme *GoDMEConfig) AddFolder(newFolder FolderDetail) (*FolderDetail, error) {
+	return dme.AddFolderContext(context.Background(), newFolder)
+}
+
+// AddFolderContext is the same as AddFolder(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) AddFolderContext(ctx context.Context, newFolder FolderDetail) (*FolderDetail, error) {
+	bodyData, err := json.Marshal(newFolder)
+	if err != nil {
+		return nil, err
+	}
+	bodyBuffer := bytes.NewReader(bodyData)
+	req, err := dme.newRequest(ctx, "POST", "security/folder", bodyBuffer)
+	if err != nil {
+		return nil, err
+	}
+
+	returnedFolder := &FolderDetail{}
+	err = dme.doDMERequest(req, returnedFolder)
+	if err != nil {
+		return nil, err
+	}
+	return returnedFolder, err
+}
+
 // UpdateRecord updates an existing DNS record (identified by its ID) in a given domain. DNS Made Easy only returns success/fail for this method.
+// As with AddRecord(), GTD locations other than GTDDefault are only allowed in domains with GTD enabled.
 func (dme *GoDMEConfig) UpdateRecord(DomainID int, Record *Record) error {
+	return dme.UpdateRecordContext(context.Background(), DomainID, Record)
+}
+
+// UpdateRecordContext is the same as UpdateRecord(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) UpdateRecordContext(ctx context.Context, DomainID int, Record *Record) error {
+	err := Record.Validate()
+	if err != nil {
+		return err
+	}
+	err = dme.checkGTDLocations(ctx, DomainID, *Record)
+	if err != nil {
+		return err
+	}
 	reqStub := fmt.Sprintf("dns/managed/%v/records/%v", DomainID, Record.ID)
 	bodyData, err := json.Marshal(Record)
 	if err != nil {
 		return err
 	}
-	return dme.genericUpdate(reqStub, bodyData)
+	return dme.genericUpdate(ctx, reqStub, bodyData)
+}
+
+// UpdateRecords updates many existing DNS records (identified by their IDs) in a given domain using DNS Made Easy's bulk update endpoint.
+// Large batches are split into chunks of BulkChunkSize records. If any chunks fail, the other chunks are still sent, and a *BulkError
+// describing each failed chunk is returned.
+func (dme *GoDMEConfig) UpdateRecords(DomainID int, Records []Record) error {
+	return dme.UpdateRecordsContext(context.Background(), DomainID, Records)
+}
+
+// UpdateRecordsContext is the same as UpdateRecords(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) UpdateRecordsContext(ctx context.Context, DomainID int, Records []Record) error {
+	err := validateRecords(Records)
+	if err != nil {
+		return err
+	}
+	err = dme.checkGTDLocations(ctx, DomainID, Records...)
+	if err != nil {
+		return err
+	}
+	reqStub := fmt.Sprintf("dns/managed/%v/records/updateMulti", DomainID)
+	return dme.eachChunk(ctx, Records, func(chunk []Record) error {
+		bodyData, err := json.Marshal(chunk)
+		if err != nil {
+			return err
+		}
+		return dme.genericUpdate(ctx, reqStub, bodyData)
+	})
+}
+
+// Check every record in a bulk request before sending any of them, so that one bad record doesn't leave the batch half sent
+func validateRecords(Records []Record) error {
+	for i := range Records {
+		err := Records[i].Validate()
+		if err != nil {
+			return fmt.Errorf("record %v of %v: %w", i+1, len(Records), err)
+		}
+	}
+	return nil
+}
+
+// The bulk endpoints need big batches split up, so this hands fn one chunk at a time and gathers up the errors from any chunks that fail.
+// We stop early if ctx is done, because every chunk after that would fail anyway.
+func (dme *GoDMEConfig) eachChunk(ctx context.Context, Records []Record, fn func([]Record) error) error {
+	chunkSize := dme.BulkChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultBulkChunkSize
+	}
+
+	bulkError := &BulkError{}
+	for offset := 0; offset < len(Records); offset += chunkSize {
+		end := offset + chunkSize
+		if end > len(Records) {
+			end = len(Records)
+		}
+		err := fn(Records[offset:end])
+		if err != nil {
+			bulkError.Chunks = append(bulkError.Chunks, BulkChunkError{
+				Offset:  offset,
+				Records: Records[offset:end],
+				Err:     err,
+			})
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if len(bulkError.Chunks) > 0 {
+		return bulkError
+	}
+	return ctx.Err()
 }
 
 // UpdateVanity updates an existing Vanity DNS Template (identified by its ID) for your account. DNS Made Easy only returns success/fail for this method.
 func (dme *GoDMEConfig) UpdateVanity(Vanity *Vanity) error {
+	return dme.UpdateVanityContext(context.Background(), Vanity)
+}
+
+// UpdateVanityContext is the same as UpdateVanity(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) UpdateVanityContext(ctx context.Context, Vanity *Vanity) error {
 	reqStub := fmt.Sprintf("dns/vanity/%v", Vanity.ID)
 	bodyData, err := json.Marshal(Vanity)
 	if err != nil {
 		return err
 	}
-	return dme.genericUpdate(reqStub, bodyData)
+	return dme.genericUpdate(ctx, reqStub, bodyData)
 }
 
 // UpdateDomain updates an existing Domain (identified by its ID) for your account. DNS Made Easy only returns success/fail for this method.
 func (dme *GoDMEConfig) UpdateDomain(Domain *Domain) error {
+	return dme.UpdateDomainContext(context.Background(), Domain)
+}
+
+// UpdateDomainContext is the same as UpdateDomain(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) UpdateDomainContext(ctx context.Context, Domain *Domain) error {
 	reqStub := fmt.Sprintf("dns/managed/%v", Domain.ID)
 	bodyData, err := json.Marshal(Domain)
 	if err != nil {
 		return err
 	}
-	return dme.genericUpdate(reqStub, bodyData)
+	return dme.genericUpdate(ctx, reqStub, bodyData)
 }
 
 // UpdateSOA updates an existing Domain (identified by its ID) for your account. DNS Made Easy only returns success/fail for this method.
 func (dme *GoDMEConfig) UpdateSOA(SOA *SOA) error {
+	return dme.UpdateSOAContext(context.Background(), SOA)
+}
+
+// UpdateSOAContext is the same as UpdateSOA(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) UpdateSOAContext(ctx context.Context, SOA *SOA) error {
 	reqStub := fmt.Sprintf("dns/soa/%v", SOA.ID)
 	bodyData, err := json.Marshal(SOA)
 	if err != nil {
 		return err
 	}
-	return dme.genericUpdate(reqStub, bodyData)
+	return dme.genericUpdate(ctx, reqStub, bodyData)
 }
 
 // UpdateIPSet updates an existing IPSet (identified by its ID) for your account. DNS Made Easy only returns success/fail for this method.
 func (dme *GoDMEConfig) UpdateIPSet(IPSet *IPSet) error {
+	return dme.UpdateIPSetContext(context.Background(), IPSet)
+}
+
+// UpdateIPSetContext is the same as UpdateIPSet(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) UpdateIPSetContext(ctx context.Context, IPSet *IPSet) error {
 	reqStub := fmt.Sprintf("dns/secondary/ipSet/%v", IPSet.ID)
 	bodyData, err := json.Marshal(IPSet)
 	if err != nil {
 		return err
 	}
-	return dme.genericUpdate(reqStub, bodyData)
+	return dme.genericUpdate(ctx, reqStub, bodyData)
+}
+
+// UpdateTransferACL updates an existing transfer ACL (identified by its ID) for your account. DNS Made Easy only returns success/fail for this method.
+func (dme *GoDMEConfig) UpdateTransferACL(TransferACL *TransferACL) error {
+	return dme.UpdateTransferACLContext(context.Background(), TransferACL)
+}
+
+// UpdateTransferACLContext is the same as UpdateTransferACL(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) UpdateTransferACLContext(ctx context.Context, TransferACL *TransferACL) error {
+	reqStub := fmt.Sprintf("dns/transferAcl/%v", TransferACL.ID)
+	bodyData, err := json.Marshal(TransferACL)
+	if err != nil {
+		return err
+	}
+	return dme.genericUpdate(ctx, reqStub, bodyData)
 }
 
 // UpdateSecondaryDomain updates an existing secondary domain (identified by its ID) for your account. DNS Made Easy only returns success/fail for this method.
 func (dme *GoDMEConfig) UpdateSecondaryDomain(SecondaryDomain *SecondaryDomain) error {
+	return dme.UpdateSecondaryDomainContext(context.Background(), SecondaryDomain)
+}
+
+// UpdateSecondaryDomainContext is the same as UpdateSecondaryDomain(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) UpdateSecondaryDomainContext(ctx context.Context, SecondaryDomain *SecondaryDomain) error {
 	reqStub := fmt.Sprintf("dns/secondary/%v", SecondaryDomain.ID)
 	bodyData, err := json.Marshal(SecondaryDomain)
 	if err != nil {
 		return err
 	}
-	return dme.genericUpdate(reqStub, bodyData)
+	return dme.genericUpdate(ctx, reqStub, bodyData)
+}
+
+// UpdateFolder updates an existing folder (identified by its ID) for your account. DNS Made Easy only returns success/fail for this method.
+func (dme *GoDMEConfig) UpdateFolder(Folder *FolderDetail) error {
+	return dme.UpdateFolderContext(context.Background(), Folder)
+}
+
+// UpdateFolderContext is the same as UpdateFolder(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) UpdateFolderContext(ctx context.Context, Folder *FolderDetail) error {
+	reqStub := fmt.Sprintf("security/folder/%v", Folder.ID)
+	bodyData, err := json.Marshal(Folder)
+	if err != nil {
+		return err
+	}
+	return dme.genericUpdate(ctx, reqStub, bodyData)
+}
+
+// MoveDomainToFolder moves a domain into a folder (identified by its ID), and updates Domain.FolderID to match. If the move fails, Domain is left as it was.
+func (dme *GoDMEConfig) MoveDomainToFolder(Domain *Domain, FolderID int) error {
+	return dme.MoveDomainToFolderContext(context.Background(), Domain, FolderID)
+}
+
+// MoveDomainToFolderContext is the same as MoveDomainToFolder(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) MoveDomainToFolderContext(ctx context.Context, Domain *Domain, FolderID int) error {
+	oldFolderID := Domain.FolderID
+	Domain.FolderID = FolderID
+	err := dme.UpdateDomainContext(ctx, Domain)
+	if err != nil {
+		Domain.FolderID = oldFolderID
+	}
+	return err
+}
+
+// MoveSecondaryDomainToFolder moves a secondary domain into a folder (identified by its ID), and updates SecondaryDomain.FolderID to match.
+// If the move fails, SecondaryDomain is left as it was.
+func (dme *GoDMEConfig) MoveSecondaryDomainToFolder(SecondaryDomain *SecondaryDomain, FolderID int) error {
+	return dme.MoveSecondaryDomainToFolderContext(context.Background(), SecondaryDomain, FolderID)
+}
+
+// MoveSecondaryDomainToFolderContext is the same as MoveSecondaryDomainToFolder(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) MoveSecondaryDomainToFolderContext(ctx context.Context, SecondaryDomain *SecondaryDomain, FolderID int) error {
+	oldFolderID := SecondaryDomain.FolderID
+	SecondaryDomain.FolderID = FolderID
+	err := dme.UpdateSecondaryDomainContext(ctx, SecondaryDomain)
+	if err != nil {
+		SecondaryDomain.FolderID = oldFolderID
+	}
+	return err
 }
 
 // All of the PUT updates are basically the same, so we can make a fairly generic wrapper
-func (dme *GoDMEConfig) genericUpdate(Endpoint string, BodyData []byte) error {
+func (dme *GoDMEConfig) genericUpdate(ctx context.Context, Endpoint string, BodyData []byte) error {
 	bodyBuffer := bytes.NewReader(BodyData)
-	req, err := dme.newRequest("PUT", Endpoint, bodyBuffer)
+	req, err := dme.newRequest(ctx, "PUT", Endpoint, bodyBuffer)
 	if err != nil {
 		return err
 	}
 	return dme.doDMERequest(req, nil)
 }
 
+// All of the list endpoints return their data in pages, so this fetches each page in turn and hands its data to fn, until we run
+// out of pages or fn returns an error
+func (dme *GoDMEConfig) eachPage(ctx context.Context, Endpoint string, fn func(json.RawMessage) error) error {
+	pageSize := dme.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	separator := "?"
+	if strings.Contains(Endpoint, "?") {
+		separator = "&"
+	}
+
+	for page := 0; ; page++ {
+		reqStub := fmt.Sprintf("%s%srows=%v&page=%v", Endpoint, separator, pageSize, page)
+		req, err := dme.newRequest(ctx, "GET", reqStub, nil)
+		if err != nil {
+			return err
+		}
+
+		genericResponse := &GenericResponse{}
+		err = dme.doDMERequest(req, &genericResponse)
+		if err != nil {
+			return err
+		}
+
+		//An empty list comes back with no data at all
+		if len(genericResponse.Data) > 0 {
+			err = fn(genericResponse.Data)
+			if err != nil {
+				return err
+			}
+		}
+
+		if page+1 >= genericResponse.TotalPages {
+			return nil
+		}
+	}
+}
+
 // DeleteRecord deletes an existing DNS record (identified by its ID) in a given domain
 func (dme *GoDMEConfig) DeleteRecord(DomainID, RecordID int) error {
+	return dme.DeleteRecordContext(context.Background(), DomainID, RecordID)
+}
+
+// DeleteRecordContext is the same as DeleteRecord(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) DeleteRecordContext(ctx context.Context, DomainID, RecordID int) error {
 	reqStub := fmt.Sprintf("dns/managed/%v/records/%v", DomainID, RecordID)
-	req, err := dme.newRequest("DELETE", reqStub, nil)
+	req, err := dme.newRequest(ctx, "DELETE", reqStub, nil)
 	if err != nil {
 		return err
 	}
@@ -354,12 +1022,17 @@ func (dme *GoDMEConfig) DeleteRecord(DomainID, RecordID int) error {
 
 // DeleteRecords deletes a DNS record (identified by their IDs) in a given domain
 func (dme *GoDMEConfig) DeleteRecords(DomainID int, RecordIDs []int) error {
+	return dme.DeleteRecordsContext(context.Background(), DomainID, RecordIDs)
+}
+
+// DeleteRecordsContext is the same as DeleteRecords(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) DeleteRecordsContext(ctx context.Context, DomainID int, RecordIDs []int) error {
 	var queryString string
 	for _, record := range RecordIDs {
 		queryString = fmt.Sprintf("%sids=%v&", queryString, record)
 	}
 	reqStub := fmt.Sprintf("dns/managed/%v/records?%s", DomainID, queryString)
-	req, err := dme.newRequest("DELETE", reqStub, nil)
+	req, err := dme.newRequest(ctx, "DELETE", reqStub, nil)
 	if err != nil {
 		return err
 	}
@@ -370,36 +1043,83 @@ func (dme *GoDMEConfig) DeleteRecords(DomainID int, RecordIDs []int) error {
 // delete the domain if DNS Made Easy says it can't delete the domain due to a pending operation. In these cases, usually deleting a domain
 // name will succeed after a certain period of time. You may not want to wait for this time though, so specify 0 here to never retry.
 func (dme *GoDMEConfig) DeleteDomain(DomainID int, DeleteTimeout time.Duration) error {
-	return dme.genericDelete(fmt.Sprintf("dns/managed/%v", DomainID), DeleteTimeout)
+	return dme.DeleteDomainContext(context.Background(), DomainID, DeleteTimeout)
+}
+
+// DeleteDomainContext is the same as DeleteDomain(), but the request can be cancelled or given a deadline with ctx. Cancelling ctx
+// also stops any further delete retries straight away, regardless of DeleteTimeout.
+func (dme *GoDMEConfig) DeleteDomainContext(ctx context.Context, DomainID int, DeleteTimeout time.Duration) error {
+	return dme.genericDelete(ctx, fmt.Sprintf("dns/managed/%v", DomainID), DeleteTimeout)
 }
 
 // DeleteSOA deletes an existing SOA record (identified by its ID). The SOA must not be in use before deleting.
 func (dme *GoDMEConfig) DeleteSOA(SoaID int) error {
-	return dme.genericDelete(fmt.Sprintf("dns/soa/%v", SoaID), 0)
+	return dme.DeleteSOAContext(context.Background(), SoaID)
+}
+
+// DeleteSOAContext is the same as DeleteSOA(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) DeleteSOAContext(ctx context.Context, SoaID int) error {
+	return dme.genericDelete(ctx, fmt.Sprintf("dns/soa/%v", SoaID), 0)
 }
 
 // DeleteVanity deletes an existing Vanity record (identified by its ID). The Vanity configuration must not be in use before deleting.
 func (dme *GoDMEConfig) DeleteVanity(VanityID int) error {
-	return dme.genericDelete(fmt.Sprintf("dns/vanity/%v", VanityID), 0)
+	return dme.DeleteVanityContext(context.Background(), VanityID)
+}
+
+// DeleteVanityContext is the same as DeleteVanity(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) DeleteVanityContext(ctx context.Context, VanityID int) error {
+	return dme.genericDelete(ctx, fmt.Sprintf("dns/vanity/%v", VanityID), 0)
 }
 
 // DeleteIPSet deletes an existing IPSet (identified by its ID). The IPSet must not be in use before deleting.
 func (dme *GoDMEConfig) DeleteIPSet(IPsetID int) error {
-	return dme.genericDelete(fmt.Sprintf("dns/secondary/ipSet/%v", IPsetID), 0)
+	return dme.DeleteIPSetContext(context.Background(), IPsetID)
+}
+
+// DeleteIPSetContext is the same as DeleteIPSet(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) DeleteIPSetContext(ctx context.Context, IPsetID int) error {
+	return dme.genericDelete(ctx, fmt.Sprintf("dns/secondary/ipSet/%v", IPsetID), 0)
+}
+
+// DeleteTransferACL deletes an existing transfer ACL (identified by its ID). The transfer ACL must not be in use before deleting.
+func (dme *GoDMEConfig) DeleteTransferACL(TransferACLID int) error {
+	return dme.DeleteTransferACLContext(context.Background(), TransferACLID)
+}
+
+// DeleteTransferACLContext is the same as DeleteTransferACL(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) DeleteTransferACLContext(ctx context.Context, TransferACLID int) error {
+	return dme.genericDelete(ctx, fmt.Sprintf("dns/transferAcl/%v", TransferACLID), 0)
 }
 
 // DeleteSecondaryDomain deletes a secondary domain from your DNS Made Easy account. The DeleteTimeout argument indicates how long we should keep trying to
 // delete the domain if DNS Made Easy says it can't delete the domain due to a pending operation. In these cases, usually deleting a domain
 // name will succeed after a certain period of time. You may not want to wait for this time though, so specify 0 here to never retry.
 func (dme *GoDMEConfig) DeleteSecondaryDomain(SecondaryDomainID int, DeleteTimeout time.Duration) error {
-	return dme.genericDelete(fmt.Sprintf("dns/secondary/%v", SecondaryDomainID), DeleteTimeout)
+	return dme.DeleteSecondaryDomainContext(context.Background(), SecondaryDomainID, DeleteTimeout)
+}
+
+// DeleteSecondaryDomainContext is the same as DeleteSecondaryDomain(), but the request can be cancelled or given a deadline with ctx.
+// Cancelling ctx also stops any further delete retries straight away, regardless of DeleteTimeout.
+func (dme *GoDMEConfig) DeleteSecondaryDomainContext(ctx context.Context, SecondaryDomainID int, DeleteTimeout time.Duration) error {
+	return dme.genericDelete(ctx, fmt.Sprintf("dns/secondary/%v", SecondaryDomainID), DeleteTimeout)
 }
 
-//All deletes are the same, but a different API endpoint, and some need a timeout.
-func (dme *GoDMEConfig) genericDelete(Endpoint string, DeleteTimeout time.Duration) error {
+// DeleteFolder deletes an existing folder (identified by its ID). The folder should be emptied before deleting.
+func (dme *GoDMEConfig) DeleteFolder(FolderID int) error {
+	return dme.DeleteFolderContext(context.Background(), FolderID)
+}
+
+// DeleteFolderContext is the same as DeleteFolder(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) DeleteFolderContext(ctx context.Context, FolderID int) error {
+	return dme.genericDelete(ctx, fmt.Sprintf("security/folder/%v", FolderID), 0)
+}
+
+// All deletes are the same, but a different API endpoint, and some need a timeout.
+func (dme *GoDMEConfig) genericDelete(ctx context.Context, Endpoint string, DeleteTimeout time.Duration) error {
 	timeOutAt := time.Now().Add(DeleteTimeout)
 
-	req, err := dme.newRequest("DELETE", Endpoint, nil)
+	req, err := dme.newRequest(ctx, "DELETE", Endpoint, nil)
 	if err != nil {
 		return err
 	}
@@ -411,71 +1131,168 @@ func (dme *GoDMEConfig) genericDelete(Endpoint string, DeleteTimeout time.Durati
 
 	//If we were unsuccessful in deleting the first time, try try again until the timeout
 	for time.Now().Before(timeOutAt) {
-		req, _ := dme.newRequest("DELETE", Endpoint, nil)
-		deleteError := dme.doDMERequest(req, nil)
+		//Don't keep retrying if whoever called us has given up
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+		}
+
+		req, err := dme.newRequest(ctx, "DELETE", Endpoint, nil)
+		if err != nil {
+			return err
+		}
+		deleteError = dme.doDMERequest(req, nil)
 		//No error? Then we're all done.
 		if deleteError == nil {
 			return deleteError
 		}
 		//We got a different error this time that is not a pending delete error
-		if deleteError.Error() != pendingDeleteError {
+		if !IsPendingAction(deleteError) {
 			return deleteError
 		}
-		time.Sleep(5 * time.Second)
 	}
 
-	return fmt.Errorf("Could not delete after %s (%s)", DeleteTimeout.String(), err)
+	return fmt.Errorf("Could not delete after %s (%s)", DeleteTimeout.String(), deleteError)
 
 }
 
 // ExportAllDomains returns a map with every domain that DNS Made Easy manages, along with its properties
 func (dme *GoDMEConfig) ExportAllDomains() (*AllDomainExport, error) {
-	allDomains, err := dme.Domains()
+	return dme.ExportAllDomainsContext(context.Background())
+}
+
+// ExportAllDomainsContext is the same as ExportAllDomains(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) ExportAllDomainsContext(ctx context.Context) (*AllDomainExport, error) {
+	return dme.ExportAllDomainsWithOptionsContext(ctx, ExportOptions{})
+}
+
+// ExportAllDomainsWithOptions is the same as ExportAllDomains(), but Options can ask for extra data to be included in the export,
+// and change how it is fetched. The records of several domains are fetched at once (see ExportOptions.Concurrency). If any domains
+// fail, the rest are still exported, and returned along with an *ExportError giving the error from each domain that failed.
+func (dme *GoDMEConfig) ExportAllDomainsWithOptions(Options ExportOptions) (*AllDomainExport, error) {
+	return dme.ExportAllDomainsWithOptionsContext(context.Background(), Options)
+}
+
+// ExportAllDomainsWithOptionsContext is the same as ExportAllDomainsWithOptions(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) ExportAllDomainsWithOptionsContext(ctx context.Context, Options ExportOptions) (*AllDomainExport, error) {
+	allDomains, err := dme.DomainsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	allSOA, err := dme.SOAContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	allSOA, err := dme.SOA()
+	allVanity, err := dme.VanityContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	allVanity, err := dme.Vanity()
+	allTransferACLs, err := dme.TransferACLsContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	concurrency := Options.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultExportConcurrency
+	}
+
 	thisExport := make(AllDomainExport)
+	exportError := &ExportError{Domains: map[string]error{}}
+	var exportLock sync.Mutex
+	var domainsDone int
+
+	//Each worker takes the next domain to export, until there are none left. The rate limiter is shared, so the workers take turns
+	//if the account is running low on requests.
+	domainIndexes := make(chan int)
+	var workers sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for i := range domainIndexes {
+				thisDomain := allDomains[i]
+				domainExport, err := dme.exportDomain(ctx, thisDomain, allSOA, allVanity, allTransferACLs, Options)
+
+				exportLock.Lock()
+				if err != nil {
+					exportError.Domains[thisDomain.Name] = err
+				} else {
+					thisExport[thisDomain.Name] = *domainExport
+				}
+				domainsDone++
+				if Options.Progress != nil {
+					Options.Progress(domainsDone, len(allDomains), thisDomain.Name)
+				}
+				exportLock.Unlock()
+			}
+		}()
+	}
+	for i := range allDomains {
+		domainIndexes <- i
+	}
+	close(domainIndexes)
+	workers.Wait()
 
-	for _, domain := range allDomains {
-		var thisSOA *SOA
-		var thisVanity *Vanity
+	if len(exportError.Domains) > 0 {
+		return &thisExport, exportError
+	}
+	return &thisExport, nil
+}
 
-		//Find the correct SOA record
-		for _, s := range allSOA {
-			if s.ID == domain.SoaID {
-				thisSOA = &s
-			}
+// exportDomain fetches the records (and failover, if asked for) of a single domain for ExportAllDomainsWithOptions. Each export gets
+// its own copies of the domain, SOA, vanity NS and transfer ACL, so that changing one export doesn't change another.
+func (dme *GoDMEConfig) exportDomain(ctx context.Context, Domain Domain, AllSOA []SOA, AllVanity []Vanity, AllTransferACLs []TransferACL, Options ExportOptions) (*DomainExport, error) {
+	thisExport := &DomainExport{
+		Info: &Domain,
+	}
+
+	//Find the correct SOA record
+	for _, s := range AllSOA {
+		if s.ID == Domain.SoaID {
+			thisSOA := s
+			thisExport.SOA = &thisSOA
 		}
+	}
 
-		//Find the correct NS records
-		for _, v := range allVanity {
-			if v.ID == domain.VanityID {
-				thisVanity = &v
-			}
+	//Find the correct NS records
+	for _, v := range AllVanity {
+		if v.ID == Domain.VanityID {
+			thisVanity := v
+			thisExport.DefaultNS = &thisVanity
 		}
+	}
 
-		//Get DNS records
-		thisRecords, err := dme.Records(domain.ID)
-		if err != nil {
-			return nil, err
+	//Find the correct transfer ACL
+	for _, a := range AllTransferACLs {
+		if a.ID == Domain.TransferAclID {
+			thisTransferACL := a
+			thisExport.TransferACL = &thisTransferACL
 		}
+	}
 
-		thisExport[domain.Name] = DomainExport{
-			Info:      &domain,
-			SOA:       thisSOA,
-			DefaultNS: thisVanity,
-			Records:   &thisRecords,
+	//Get DNS records
+	thisRecords, err := dme.RecordsContext(ctx, Domain.ID)
+	if err != nil {
+		return nil, err
+	}
+	thisExport.Records = &thisRecords
+
+	//Get failover settings for the records that have any
+	if Options.IncludeFailover {
+		thisExport.Failover = make(map[int]*Failover)
+		for _, r := range thisRecords {
+			if !r.Monitor && !r.Failover {
+				continue
+			}
+			recordFailover, err := dme.FailoverContext(ctx, r.ID)
+			if err != nil {
+				return nil, err
+			}
+			thisExport.Failover[r.ID] = recordFailover
 		}
 	}
 
-	return &thisExport, nil
+	return thisExport, nil
 }
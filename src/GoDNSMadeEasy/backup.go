@@ -0,0 +1,506 @@
+package GoDNSMadeEasy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// BackupVersion is the version of the backup format written by this package. Backups with any other version are rejected by ReadBackup().
+const BackupVersion = 1
+
+// DefaultRestoreWaitTimeout is how long Restore waits for each new domain to be ready for its records, unless RestoreOptions.WaitTimeout
+// says otherwise
+const DefaultRestoreWaitTimeout = 5 * time.Minute
+
+// Backup is a snapshot of an account: its managed domains (with their records and failover), secondary domains, custom SOAs, vanity
+// name servers, IP sets, transfer ACLs, folders and contact lists. Templates are not included. Save it with Write() and load it again
+// with ReadBackup().
+type Backup struct {
+	Version          int               `json:"version"`
+	Created          time.Time         `json:"created"`
+	SOA              []SOA             `json:"soa"`
+	Vanity           []Vanity          `json:"vanity"`
+	IPSets           []IPSet           `json:"ipSets"`
+	TransferACLs     []TransferACL     `json:"transferAcls"`
+	Folders          []FolderDetail    `json:"folders"`
+	ContactLists     []ContactList     `json:"contactLists"`
+	Domains          []DomainBackup    `json:"domains"`
+	SecondaryDomains []SecondaryDomain `json:"secondaryDomains"`
+}
+
+// DomainBackup is a managed domain in a Backup, with its records
+type DomainBackup struct {
+	Domain  Domain   `json:"domain"`
+	Records []Record `json:"records"`
+	// Failover is the failover configuration of each record that has monitoring or failover turned on, keyed by record ID
+	Failover map[int]*Failover `json:"failover,omitempty"`
+}
+
+// RestoreOptions changes how Restore() recreates a backup
+type RestoreOptions struct {
+	// SkipExistingDomains leaves alone any managed or secondary domains that are already in the account (they are listed in
+	// RestoreResult.Skipped). Otherwise Restore refuses to run if any of them exist.
+	SkipExistingDomains bool
+	// WaitTimeout is how long to wait for each new domain to be ready before adding its records. If omitted, this defaults to
+	// DefaultRestoreWaitTimeout
+	WaitTimeout time.Duration
+}
+
+// RestoreResult maps the IDs of everything in a backup to the IDs of the same things in the account it was restored into
+type RestoreResult struct {
+	SOAIDs             map[int]int
+	VanityIDs          map[int]int
+	IPSetIDs           map[int]int
+	TransferACLIDs     map[int]int
+	FolderIDs          map[int]int
+	ContactListIDs     map[int]int
+	DomainIDs          map[int]int
+	SecondaryDomainIDs map[int]int
+	// Skipped are the names of domains that were already in the account, when RestoreOptions.SkipExistingDomains is set
+	Skipped []string
+	// SkippedFailover are the records (as "name TYPE") that had monitoring or failover turned on, but have no failover configuration in
+	// the backup. They are restored with monitoring and failover turned off.
+	SkippedFailover []string
+}
+
+// ReadBackup reads a backup written by Backup.Write()
+func ReadBackup(r io.Reader) (*Backup, error) {
+	thisBackup := &Backup{}
+	err := json.NewDecoder(r).Decode(thisBackup)
+	if err != nil {
+		return nil, err
+	}
+	if thisBackup.Version != BackupVersion {
+		return nil, fmt.Errorf("backup version %v is not supported (expected version %v)", thisBackup.Version, BackupVersion)
+	}
+	return thisBackup, nil
+}
+
+// Write writes the backup as JSON
+func (b *Backup) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(b)
+}
+
+// Backup takes a snapshot of everything in the account that Restore() can put back (see Backup)
+func (dme *GoDMEConfig) Backup() (*Backup, error) {
+	return dme.BackupContext(context.Background())
+}
+
+// BackupContext is the same as Backup(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) BackupContext(ctx context.Context) (*Backup, error) {
+	var err error
+	thisBackup := &Backup{
+		Version: BackupVersion,
+		Created: time.Now().UTC(),
+	}
+
+	if thisBackup.SOA, err = dme.SOAContext(ctx); err != nil {
+		return nil, err
+	}
+	if thisBackup.Vanity, err = dme.VanityContext(ctx); err != nil {
+		return nil, err
+	}
+	if thisBackup.IPSets, err = dme.IPSetsContext(ctx); err != nil {
+		return nil, err
+	}
+	if thisBackup.TransferACLs, err = dme.TransferACLsContext(ctx); err != nil {
+		return nil, err
+	}
+	if thisBackup.SecondaryDomains, err = dme.SecondaryDomainsContext(ctx); err != nil {
+		return nil, err
+	}
+	if thisBackup.ContactLists, err = dme.ContactListsContext(ctx); err != nil {
+		return nil, err
+	}
+
+	allFolders, err := dme.FoldersContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, thisFolder := range allFolders {
+		folderDetail, err := dme.FolderContext(ctx, thisFolder.Value)
+		if err != nil {
+			return nil, err
+		}
+		thisBackup.Folders = append(thisBackup.Folders, *folderDetail)
+	}
+
+	allDomains, err := dme.DomainsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, thisDomain := range allDomains {
+		domainRecords, err := dme.RecordsContext(ctx, thisDomain.ID)
+		if err != nil {
+			return nil, fmt.Errorf("domain %s: %w", thisDomain.Name, err)
+		}
+		domainBackup := DomainBackup{Domain: thisDomain, Records: domainRecords}
+		for _, thisRecord := range domainRecords {
+			if !thisRecord.Monitor && !thisRecord.Failover {
+				continue
+			}
+			recordFailover, err := dme.FailoverContext(ctx, thisRecord.ID)
+			if err != nil {
+				return nil, fmt.Errorf("domain %s: failover of record %v: %w", thisDomain.Name, thisRecord.ID, err)
+			}
+			if domainBackup.Failover == nil {
+				domainBackup.Failover = make(map[int]*Failover)
+			}
+			domainBackup.Failover[thisRecord.ID] = recordFailover
+		}
+		thisBackup.Domains = append(thisBackup.Domains, domainBackup)
+	}
+
+	return thisBackup, nil
+}
+
+// Restore recreates a backup in an account, which would normally be empty (such as a new sandbox account). Custom SOAs, vanity name
+// servers, IP sets, transfer ACLs, folders and contact lists that already exist in the account with the same name are used as they are,
+// rather than being created again. Public vanity name servers and the default folder can't be created, so they must already exist. The
+// domains are then created using the new IDs of everything they refer to, and their records are added once each domain is ready. Last
+// of all, failover is set up again on the records that had it, using their new IDs.
+//
+// Restore stops at the first error. The result is still returned, with the IDs of everything restored before the error.
+func (dme *GoDMEConfig) Restore(Archive *Backup, Options RestoreOptions) (*RestoreResult, error) {
+	return dme.RestoreContext(context.Background(), Archive, Options)
+}
+
+// RestoreContext is the same as Restore(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) RestoreContext(ctx context.Context, Archive *Backup, Options RestoreOptions) (*RestoreResult, error) {
+	if Options.WaitTimeout <= 0 {
+		Options.WaitTimeout = DefaultRestoreWaitTimeout
+	}
+	result := &RestoreResult{
+		SOAIDs:             map[int]int{},
+		VanityIDs:          map[int]int{},
+		IPSetIDs:           map[int]int{},
+		TransferACLIDs:     map[int]int{},
+		FolderIDs:          map[int]int{},
+		ContactListIDs:     map[int]int{},
+		DomainIDs:          map[int]int{},
+		SecondaryDomainIDs: map[int]int{},
+	}
+
+	//Check for domains that are already there before changing anything
+	existingDomains := map[string]bool{}
+	allDomains, err := dme.DomainsContext(ctx)
+	if err != nil {
+		return result, err
+	}
+	for _, thisDomain := range allDomains {
+		existingDomains[normaliseDomainName(thisDomain.Name)] = true
+	}
+	allSecondaryDomains, err := dme.SecondaryDomainsContext(ctx)
+	if err != nil {
+		return result, err
+	}
+	for _, thisSecondaryDomain := range allSecondaryDomains {
+		existingDomains[normaliseDomainName(thisSecondaryDomain.Name)] = true
+	}
+	if !Options.SkipExistingDomains {
+		for _, thisName := range Archive.domainNames() {
+			if existingDomains[thisName] {
+				return result, fmt.Errorf("domain %s is already in the account: %w", thisName, ErrDuplicate)
+			}
+		}
+	}
+
+	err = dme.restoreShared(ctx, Archive, result)
+	if err != nil {
+		return result, err
+	}
+
+	for _, thisBackup := range Archive.Domains {
+		domainName := normaliseDomainName(thisBackup.Domain.Name)
+		if existingDomains[domainName] {
+			result.Skipped = append(result.Skipped, domainName)
+			continue
+		}
+		err := dme.restoreDomain(ctx, thisBackup, Options, result)
+		if err != nil {
+			return result, fmt.Errorf("domain %s: %w", domainName, err)
+		}
+	}
+
+	for _, thisSecondaryDomain := range Archive.SecondaryDomains {
+		domainName := normaliseDomainName(thisSecondaryDomain.Name)
+		if existingDomains[domainName] {
+			result.Skipped = append(result.Skipped, domainName)
+			continue
+		}
+		err := dme.restoreSecondaryDomain(ctx, thisSecondaryDomain, result)
+		if err != nil {
+			return result, fmt.Errorf("secondary domain %s: %w", domainName, err)
+		}
+	}
+
+	return result, nil
+}
+
+func (b *Backup) domainNames() []string {
+	var names []string
+	for _, thisBackup := range b.Domains {
+		names = append(names, normaliseDomainName(thisBackup.Domain.Name))
+	}
+	for _, thisSecondaryDomain := range b.SecondaryDomains {
+		names = append(names, normaliseDomainName(thisSecondaryDomain.Name))
+	}
+	return names
+}
+
+// restoreShared recreates the things that domains refer to by ID, reusing any that are already in the account with the same name
+func (dme *GoDMEConfig) restoreShared(ctx context.Context, Archive *Backup, Result *RestoreResult) error {
+	existingSOA, err := dme.SOAContext(ctx)
+	if err != nil {
+		return err
+	}
+	for _, thisSOA := range Archive.SOA {
+		if id := findByName(len(existingSOA), func(i int) (string, int) { return existingSOA[i].Name, existingSOA[i].ID }, thisSOA.Name); id != 0 {
+			Result.SOAIDs[thisSOA.ID] = id
+			continue
+		}
+		newSOA := thisSOA
+		newSOA.ID = 0
+		returnedSOA, err := dme.AddSOAContext(ctx, newSOA)
+		if err != nil {
+			return fmt.Errorf("custom SOA %s: %w", thisSOA.Name, err)
+		}
+		Result.SOAIDs[thisSOA.ID] = returnedSOA.ID
+	}
+
+	existingVanity, err := dme.VanityContext(ctx)
+	if err != nil {
+		return err
+	}
+	for _, thisVanity := range Archive.Vanity {
+		if id := findByName(len(existingVanity), func(i int) (string, int) { return existingVanity[i].Name, existingVanity[i].ID }, thisVanity.Name); id != 0 {
+			Result.VanityIDs[thisVanity.ID] = id
+			continue
+		}
+		if thisVanity.Public {
+			return &NotFoundError{Kind: "public vanity name servers", Name: thisVanity.Name}
+		}
+		newVanity := thisVanity
+		newVanity.ID = 0
+		newVanity.Default = false
+		returnedVanity, err := dme.AddVanityContext(ctx, newVanity)
+		if err != nil {
+			return fmt.Errorf("vanity name servers %s: %w", thisVanity.Name, err)
+		}
+		Result.VanityIDs[thisVanity.ID] = returnedVanity.ID
+	}
+
+	existingIPSets, err := dme.IPSetsContext(ctx)
+	if err != nil {
+		return err
+	}
+	for _, thisIPSet := range Archive.IPSets {
+		if id := findByName(len(existingIPSets), func(i int) (string, int) { return existingIPSets[i].Name, existingIPSets[i].ID }, thisIPSet.Name); id != 0 {
+			Result.IPSetIDs[thisIPSet.ID] = id
+			continue
+		}
+		newIPSet := thisIPSet
+		newIPSet.ID = 0
+		returnedIPSet, err := dme.AddIPSetContext(ctx, newIPSet)
+		if err != nil {
+			return fmt.Errorf("IP set %s: %w", thisIPSet.Name, err)
+		}
+		Result.IPSetIDs[thisIPSet.ID] = returnedIPSet.ID
+	}
+
+	existingTransferACLs, err := dme.TransferACLsContext(ctx)
+	if err != nil {
+		return err
+	}
+	for _, thisTransferACL := range Archive.TransferACLs {
+		if id := findByName(len(existingTransferACLs), func(i int) (string, int) { return existingTransferACLs[i].Name, existingTransferACLs[i].ID }, thisTransferACL.Name); id != 0 {
+			Result.TransferACLIDs[thisTransferACL.ID] = id
+			continue
+		}
+		newTransferACL := thisTransferACL
+		newTransferACL.ID = 0
+		returnedTransferACL, err := dme.AddTransferACLContext(ctx, newTransferACL)
+		if err != nil {
+			return fmt.Errorf("transfer ACL %s: %w", thisTransferACL.Name, err)
+		}
+		Result.TransferACLIDs[thisTransferACL.ID] = returnedTransferACL.ID
+	}
+
+	existingFolders, err := dme.FoldersContext(ctx)
+	if err != nil {
+		return err
+	}
+	for _, thisFolder := range Archive.Folders {
+		if id := findByName(len(existingFolders), func(i int) (string, int) { return existingFolders[i].Label, existingFolders[i].Value }, thisFolder.Name); id != 0 {
+			Result.FolderIDs[thisFolder.ID] = id
+			continue
+		}
+		if thisFolder.DefaultFolder {
+			return &NotFoundError{Kind: "default folder", Name: thisFolder.Name}
+		}
+		//The domains are put in the folder as they are created, and permissions refer to groups in the old account
+		returnedFolder, err := dme.AddFolderContext(ctx, FolderDetail{Name: thisFolder.Name})
+		if err != nil {
+			return fmt.Errorf("folder %s: %w", thisFolder.Name, err)
+		}
+		Result.FolderIDs[thisFolder.ID] = returnedFolder.ID
+	}
+
+	existingContactLists, err := dme.ContactListsContext(ctx)
+	if err != nil {
+		return err
+	}
+	for _, thisContactList := range Archive.ContactLists {
+		if id := findByName(len(existingContactLists), func(i int) (string, int) { return existingContactLists[i].Name, existingContactLists[i].ID }, thisContactList.Name); id != 0 {
+			Result.ContactListIDs[thisContactList.ID] = id
+			continue
+		}
+		newContactList := thisContactList
+		newContactList.ID = 0
+		returnedContactList, err := dme.AddContactListContext(ctx, newContactList)
+		if err != nil {
+			return fmt.Errorf("contact list %s: %w", thisContactList.Name, err)
+		}
+		Result.ContactListIDs[thisContactList.ID] = returnedContactList.ID
+	}
+	return nil
+}
+
+// findByName looks through n named things for one called Name, and returns its ID (or 0 if there isn't one)
+func findByName(n int, nameAndID func(int) (string, int), Name string) int {
+	for i := 0; i < n; i++ {
+		if thisName, id := nameAndID(i); strings.EqualFold(strings.TrimSpace(thisName), strings.TrimSpace(Name)) {
+			return id
+		}
+	}
+	return 0
+}
+
+// remapID turns the ID of something in a backup into its ID in the account it was restored into. An ID of 0 means nothing is used.
+func remapID(IDs map[int]int, OldID int, Kind string) (int, error) {
+	if OldID == 0 {
+		return 0, nil
+	}
+	newID, ok := IDs[OldID]
+	if !ok {
+		return 0, fmt.Errorf("%s with ID %v is not in the backup", Kind, OldID)
+	}
+	return newID, nil
+}
+
+func (dme *GoDMEConfig) restoreDomain(ctx context.Context, Archived DomainBackup, Options RestoreOptions, Result *RestoreResult) error {
+	newDomain := &Domain{
+		Name:       normaliseDomainName(Archived.Domain.Name),
+		GtdEnabled: Archived.Domain.GtdEnabled,
+	}
+	var err error
+	if newDomain.SoaID, err = remapID(Result.SOAIDs, Archived.Domain.SoaID, "custom SOA"); err != nil {
+		return err
+	}
+	if newDomain.VanityID, err = remapID(Result.VanityIDs, Archived.Domain.VanityID, "vanity name servers"); err != nil {
+		return err
+	}
+	if newDomain.TransferAclID, err = remapID(Result.TransferACLIDs, Archived.Domain.TransferAclID, "transfer ACL"); err != nil {
+		return err
+	}
+	if newDomain.FolderID, err = remapID(Result.FolderIDs, Archived.Domain.FolderID, "folder"); err != nil {
+		return err
+	}
+
+	returnedDomain, err := dme.AddDomainContext(ctx, newDomain)
+	if err != nil {
+		return err
+	}
+	Result.DomainIDs[Archived.Domain.ID] = returnedDomain.ID
+	if len(Archived.Records) == 0 {
+		return nil
+	}
+	if returnedDomain.PendingActionID != 0 {
+		_, err = dme.WaitForDomainContext(ctx, returnedDomain.ID, Options.WaitTimeout)
+		if err != nil {
+			return err
+		}
+	}
+
+	//The records get new IDs, and don't come from a template in the new account. Failover can only be set up once they have their new
+	//IDs, so it starts off turned off, and we keep track of which old records need it.
+	var newRecords []Record
+	failoverIDs := map[string][]int{}
+	for _, thisRecord := range Archived.Records {
+		if thisRecord.Monitor || thisRecord.Failover {
+			if Archived.Failover[thisRecord.ID] == nil {
+				recordName := newDomain.Name
+				if thisRecord.Name != "" {
+					recordName = thisRecord.Name + "." + recordName
+				}
+				Result.SkippedFailover = append(Result.SkippedFailover, fmt.Sprintf("%s %s", recordName, thisRecord.Type))
+			} else {
+				key := recordKey(thisRecord, true)
+				failoverIDs[key] = append(failoverIDs[key], thisRecord.ID)
+			}
+		}
+		thisRecord.ID = 0
+		thisRecord.SourceID = 0
+		thisRecord.Source = 0
+		thisRecord.Failed = false
+		thisRecord.Monitor = false
+		thisRecord.Failover = false
+		newRecords = append(newRecords, thisRecord)
+	}
+	returnedRecords, err := dme.AddRecordsContext(ctx, returnedDomain.ID, newRecords)
+	if err != nil {
+		return err
+	}
+
+	for _, thisRecord := range returnedRecords {
+		key := recordKey(thisRecord, true)
+		if len(failoverIDs[key]) == 0 {
+			continue
+		}
+		oldID := failoverIDs[key][0]
+		failoverIDs[key] = failoverIDs[key][1:]
+
+		newFailover := *Archived.Failover[oldID]
+		newFailover.RecordID = thisRecord.ID
+		newFailover.SourceID = 0
+		newFailover.Source = 0
+		if newFailover.ContactListID, err = remapID(Result.ContactListIDs, newFailover.ContactListID, "contact list"); err != nil {
+			return err
+		}
+		err = dme.UpdateFailoverContext(ctx, &newFailover)
+		if err != nil {
+			return fmt.Errorf("failover of %s record %q: %w", thisRecord.Type, thisRecord.Name, err)
+		}
+	}
+	return nil
+}
+
+func (dme *GoDMEConfig) restoreSecondaryDomain(ctx context.Context, Archived SecondaryDomain, Result *RestoreResult) error {
+	oldIPSetID := Archived.IPSetID
+	if oldIPSetID == 0 {
+		oldIPSetID = Archived.IPSet.ID
+	}
+	newSecondaryDomain := SecondaryDomain{
+		Name: normaliseDomainName(Archived.Name),
+	}
+	var err error
+	if newSecondaryDomain.IPSetID, err = remapID(Result.IPSetIDs, oldIPSetID, "IP set"); err != nil {
+		return err
+	}
+	if newSecondaryDomain.FolderID, err = remapID(Result.FolderIDs, Archived.FolderID, "folder"); err != nil {
+		return err
+	}
+
+	returnedSecondaryDomain, err := dme.AddSecondaryDomainContext(ctx, newSecondaryDomain)
+	if err != nil {
+		return err
+	}
+	Result.SecondaryDomainIDs[Archived.ID] = returnedSecondaryDomain.ID
+	return nil
+}
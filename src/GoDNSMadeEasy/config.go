@@ -0,0 +1,327 @@
+package GoDNSMadeEasy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ZoneConfigVersion is the version of the zone config format written by this package. Configs with any other version are rejected when
+// they are read, so that an old version of this package doesn't misread a newer format.
+const ZoneConfigVersion = 1
+
+// ApexName is the name used in a zone config for records belonging to the domain itself, which DNS Made Easy gives an empty name
+const ApexName = "@"
+
+// ZoneConfig describes domains and their records in a form that is meant to be kept in files (such as in git) and applied later. Unlike
+// AllDomainExport, it leaves out IDs and anything else that DNS Made Easy manages itself. Read and write it as JSON with
+// ReadZoneConfigJSON() and WriteJSON(), or as YAML with the zoneyaml package (kept separate so that this package has no dependencies).
+//
+// A config looks like this in YAML:
+//
+//	version: 1
+//	domains:
+//	  - name: example.org
+//	    ttl: 1800
+//	    records:
+//	      "@":
+//	        - type: A
+//	          value: 127.0.0.1
+//	        - type: MX
+//	          value: mail
+//	          level: 10
+//	      www:
+//	        - type: CNAME
+//	          value: example.org.
+//	          ttl: 300
+type ZoneConfig struct {
+	Version int            `json:"version" yaml:"version"`
+	Domains []DomainConfig `json:"domains" yaml:"domains"`
+}
+
+// DomainConfig is a single domain in a ZoneConfig
+type DomainConfig struct {
+	Name string `json:"name" yaml:"name"`
+	// SOA, Vanity and TransferACL are the names of the custom SOA, vanity name servers and transfer ACL the domain uses, if any
+	SOA         string `json:"soa,omitempty" yaml:"soa,omitempty"`
+	Vanity      string `json:"vanity,omitempty" yaml:"vanity,omitempty"`
+	TransferACL string `json:"transferAcl,omitempty" yaml:"transferAcl,omitempty"`
+	GTD         bool   `json:"gtd,omitempty" yaml:"gtd,omitempty"`
+	// TTL is the TTL of records that don't have one of their own. If omitted, this defaults to DefaultZoneTTL
+	TTL int `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+	// Records are the domain's records, grouped by name. Records for the domain itself are under ApexName.
+	Records map[string][]RecordConfig `json:"records,omitempty" yaml:"records,omitempty"`
+}
+
+// RecordConfig is a single record in a DomainConfig. Only the fields used by the record's type should be set.
+type RecordConfig struct {
+	Type RecordType `json:"type" yaml:"type"`
+	// Value is the address of an A or AAAA record, the host of a CNAME, ANAME, NS, PTR, MX or SRV record, the text of a TXT or SPF
+	// record (without quotes), the value of a CAA record, or the URL of an HTTPRED record
+	Value string `json:"value" yaml:"value"`
+	// TTL is the record's TTL. If omitted, this defaults to the TTL of the domain.
+	TTL         int         `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+	Location    GTDLocation `json:"location,omitempty" yaml:"location,omitempty"`
+	DynamicDNS  bool        `json:"dynamicDns,omitempty" yaml:"dynamicDns,omitempty"`
+	Level       int         `json:"level,omitempty" yaml:"level,omitempty"`
+	Priority    int         `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Weight      int         `json:"weight,omitempty" yaml:"weight,omitempty"`
+	Port        int         `json:"port,omitempty" yaml:"port,omitempty"`
+	Tag         string      `json:"tag,omitempty" yaml:"tag,omitempty"`
+	Critical    bool        `json:"critical,omitempty" yaml:"critical,omitempty"`
+	Redirect    string      `json:"redirect,omitempty" yaml:"redirect,omitempty"`
+	HardLink    bool        `json:"hardLink,omitempty" yaml:"hardLink,omitempty"`
+	Title       string      `json:"title,omitempty" yaml:"title,omitempty"`
+	Keywords    string      `json:"keywords,omitempty" yaml:"keywords,omitempty"`
+	Description string      `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// ReadZoneConfigJSON reads a zone config written in JSON. Unknown fields are an error, to catch typos.
+func ReadZoneConfigJSON(r io.Reader) (*ZoneConfig, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	thisConfig := &ZoneConfig{}
+	err := decoder.Decode(thisConfig)
+	if err != nil {
+		return nil, err
+	}
+	return thisConfig, thisConfig.CheckVersion()
+}
+
+// CheckVersion returns an error if the config is not in the version of the format written by this package (ZoneConfigVersion). Anything
+// that reads a config in another format should call this before using it.
+func (c *ZoneConfig) CheckVersion() error {
+	if c.Version != ZoneConfigVersion {
+		return fmt.Errorf("zone config version %v is not supported (expected version %v)", c.Version, ZoneConfigVersion)
+	}
+	return nil
+}
+
+// WriteJSON writes the zone config as indented JSON
+func (c *ZoneConfig) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(c)
+}
+
+// Domain returns the config of a domain by name, or nil if it isn't in the config
+func (c *ZoneConfig) Domain(Name string) *DomainConfig {
+	for i := range c.Domains {
+		if normaliseDomainName(c.Domains[i].Name) == normaliseDomainName(Name) {
+			return &c.Domains[i]
+		}
+	}
+	return nil
+}
+
+// NewZoneConfig turns an export (from ExportAllDomains()) into a zone config, with the domains in name order
+func NewZoneConfig(Export AllDomainExport) *ZoneConfig {
+	thisConfig := &ZoneConfig{Version: ZoneConfigVersion}
+	for _, thisExport := range Export {
+		thisConfig.Domains = append(thisConfig.Domains, NewDomainConfig(thisExport))
+	}
+	sort.Slice(thisConfig.Domains, func(i, j int) bool {
+		return thisConfig.Domains[i].Name < thisConfig.Domains[j].Name
+	})
+	return thisConfig
+}
+
+// NewDomainConfig turns the export of a single domain (from ExportDomain() or ExportAllDomains()) into its config. The most common TTL
+// of the records becomes the domain's TTL, and is left off the records that use it.
+func NewDomainConfig(Export DomainExport) DomainConfig {
+	thisConfig := DomainConfig{}
+	if Export.Info != nil {
+		thisConfig.Name = normaliseDomainName(Export.Info.Name)
+		thisConfig.GTD = Export.Info.GtdEnabled
+	}
+	if Export.SOA != nil {
+		thisConfig.SOA = Export.SOA.Name
+	}
+	if Export.DefaultNS != nil {
+		thisConfig.Vanity = Export.DefaultNS.Name
+	}
+	if Export.TransferACL != nil {
+		thisConfig.TransferACL = Export.TransferACL.Name
+	}
+	if Export.Records == nil || len(*Export.Records) == 0 {
+		return thisConfig
+	}
+
+	ttlCounts := map[int]int{}
+	for _, thisRecord := range *Export.Records {
+		ttlCounts[thisRecord.TTL]++
+		if ttlCounts[thisRecord.TTL] > ttlCounts[thisConfig.TTL] || (ttlCounts[thisRecord.TTL] == ttlCounts[thisConfig.TTL] && thisRecord.TTL < thisConfig.TTL) {
+			thisConfig.TTL = thisRecord.TTL
+		}
+	}
+
+	records := append([]Record{}, *Export.Records...)
+	sortRecords(records)
+	thisConfig.Records = map[string][]RecordConfig{}
+	for _, thisRecord := range records {
+		name := thisRecord.Name
+		if name == "" {
+			name = ApexName
+		}
+		recordConfig := NewRecordConfig(thisRecord)
+		if recordConfig.TTL == thisConfig.TTL {
+			recordConfig.TTL = 0
+		}
+		thisConfig.Records[name] = append(thisConfig.Records[name], recordConfig)
+	}
+	return thisConfig
+}
+
+// NewRecordConfig turns a record into its config. The record's name is not included, as records are grouped by name in a DomainConfig.
+func NewRecordConfig(r Record) RecordConfig {
+	thisConfig := RecordConfig{
+		Type:        RecordType(r.Type),
+		Value:       r.Value,
+		TTL:         r.TTL,
+		DynamicDNS:  r.DynamicDNS,
+		Level:       r.MxLevel,
+		Priority:    r.Priority,
+		Weight:      r.Weight,
+		Port:        r.Port,
+		Tag:         r.CaaType,
+		Critical:    r.IssuerCritical == CAACritical,
+		Redirect:    r.RedirectType,
+		Title:       r.Title,
+		Keywords:    r.Keywords,
+		Description: r.Description,
+	}
+	if !GTDLocation(r.GtdLocation).IsDefault() {
+		thisConfig.Location = GTDLocation(r.GtdLocation)
+	}
+	//DNS Made Easy sets hardLink on other types of record too, where it means nothing, so keep it out of the config
+	if r.Type == RecordHTTPRED {
+		thisConfig.HardLink = r.HardLink
+	}
+	if text, err := r.Text(); err == nil {
+		thisConfig.Value = text
+	}
+	return thisConfig
+}
+
+// Record turns a record config back into a record with the given name (which is ApexName or empty for the domain itself). DefaultTTL
+// is used if the config doesn't have a TTL.
+func (c RecordConfig) Record(Name string, DefaultTTL int) Record {
+	if Name == ApexName {
+		Name = ""
+	}
+	thisRecord := Record{
+		Name:         Name,
+		Type:         strings.ToUpper(string(c.Type)),
+		Value:        c.Value,
+		TTL:          c.TTL,
+		GtdLocation:  strings.ToUpper(string(c.Location)),
+		DynamicDNS:   c.DynamicDNS,
+		MxLevel:      c.Level,
+		Priority:     c.Priority,
+		Weight:       c.Weight,
+		Port:         c.Port,
+		CaaType:      c.Tag,
+		RedirectType: c.Redirect,
+		Title:        c.Title,
+		Keywords:     c.Keywords,
+		Description:  c.Description,
+	}
+	if thisRecord.TTL == 0 {
+		thisRecord.TTL = DefaultTTL
+	}
+	if thisRecord.GtdLocation == "" {
+		thisRecord.GtdLocation = GTDDefault
+	}
+	if c.Critical {
+		thisRecord.IssuerCritical = CAACritical
+	}
+	if thisRecord.Type == RecordHTTPRED {
+		thisRecord.HardLink = c.HardLink
+	}
+	if thisRecord.Type == RecordTXT || thisRecord.Type == RecordSPF {
+		thisRecord.Value = QuoteTXT(c.Value)
+	}
+	return thisRecord
+}
+
+// RecordList turns the domain's record configs back into records, in name order. Every record is checked with Record.Validate().
+func (d *DomainConfig) RecordList() ([]Record, error) {
+	ttl := d.TTL
+	if ttl <= 0 {
+		ttl = DefaultZoneTTL
+	}
+	var records []Record
+	for name, recordConfigs := range d.Records {
+		for _, recordConfig := range recordConfigs {
+			thisRecord := recordConfig.Record(name, ttl)
+			if err := thisRecord.Validate(); err != nil {
+				return nil, fmt.Errorf("domain %s: %w", d.Name, err)
+			}
+			records = append(records, thisRecord)
+		}
+	}
+	sortRecords(records)
+	return records, nil
+}
+
+// DomainFromConfig turns a domain config into a Domain, ready for AddDomain() or UpdateDomain(). The SOA, vanity name servers and
+// transfer ACL are looked up by name, and a *NotFoundError is returned if any of them don't exist. Records are not included, see
+// DomainConfig.RecordList().
+func (dme *GoDMEConfig) DomainFromConfig(Config DomainConfig) (*Domain, error) {
+	return dme.DomainFromConfigContext(context.Background(), Config)
+}
+
+// DomainFromConfigContext is the same as DomainFromConfig(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) DomainFromConfigContext(ctx context.Context, Config DomainConfig) (*Domain, error) {
+	thisDomain := &Domain{
+		Name:       normaliseDomainName(Config.Name),
+		GtdEnabled: Config.GTD,
+	}
+
+	if Config.SOA != "" {
+		allSOA, err := dme.SOAContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, thisSOA := range allSOA {
+			if strings.EqualFold(thisSOA.Name, Config.SOA) {
+				thisDomain.SoaID = thisSOA.ID
+			}
+		}
+		if thisDomain.SoaID == 0 {
+			return nil, &NotFoundError{Kind: "custom SOA", Name: Config.SOA}
+		}
+	}
+	if Config.Vanity != "" {
+		allVanity, err := dme.VanityContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, thisVanity := range allVanity {
+			if strings.EqualFold(thisVanity.Name, Config.Vanity) {
+				thisDomain.VanityID = thisVanity.ID
+			}
+		}
+		if thisDomain.VanityID == 0 {
+			return nil, &NotFoundError{Kind: "vanity name servers", Name: Config.Vanity}
+		}
+	}
+	if Config.TransferACL != "" {
+		allTransferACLs, err := dme.TransferACLsContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, thisTransferACL := range allTransferACLs {
+			if strings.EqualFold(thisTransferACL.Name, Config.TransferACL) {
+				thisDomain.TransferAclID = thisTransferACL.ID
+			}
+		}
+		if thisDomain.TransferAclID == 0 {
+			return nil, &NotFoundError{Kind: "transfer ACL", Name: Config.TransferACL}
+		}
+	}
+	return thisDomain, nil
+}
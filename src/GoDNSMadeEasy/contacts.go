@@ -0,0 +1,131 @@
+package GoDNSMadeEasy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ContactLists returns the contact lists for an account. These are who gets notified when system monitoring or failover sees an IP go down.
+func (dme *GoDMEConfig) ContactLists() ([]ContactList, error) {
+	return dme.ContactListsContext(context.Background())
+}
+
+// ContactListsContext is the same as ContactLists(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) ContactListsContext(ctx context.Context) ([]ContactList, error) {
+	contactListData := []ContactList{}
+	err := dme.eachPage(ctx, "contactList", func(pageData json.RawMessage) error {
+		pageContactLists := []ContactList{}
+		err := json.Unmarshal(pageData, &pageContactLists)
+		contactListData = append(contactListData, pageContactLists...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return contactListData, nil
+}
+
+// ContactListByName returns a single contact list, looked up by its name rather than its ID. The name is not case sensitive. If there is
+// no such contact list, the error is a *NotFoundError.
+func (dme *GoDMEConfig) ContactListByName(Name string) (*ContactList, error) {
+	return dme.ContactListByNameContext(context.Background(), Name)
+}
+
+// ContactListByNameContext is the same as ContactListByName(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) ContactListByNameContext(ctx context.Context, Name string) (*ContactList, error) {
+	listName := strings.TrimSpace(Name)
+	var foundContactList *ContactList
+	errFound := errors.New("found contact list")
+	err := dme.eachPage(ctx, "contactList", func(pageData json.RawMessage) error {
+		pageContactLists := []ContactList{}
+		err := json.Unmarshal(pageData, &pageContactLists)
+		if err != nil {
+			return err
+		}
+		for i, thisContactList := range pageContactLists {
+			if strings.EqualFold(strings.TrimSpace(thisContactList.Name), listName) {
+				foundContactList = &pageContactLists[i]
+				return errFound
+			}
+		}
+		return nil
+	})
+	if err != nil && err != errFound {
+		return nil, err
+	}
+	if foundContactList == nil {
+		return nil, &NotFoundError{Kind: "contact list", Name: listName}
+	}
+	return foundContactList, nil
+}
+
+// AddContactList creates a contact list for an account. These can then be used for failover notifications (see Failover.ContactListID).
+func (dme *GoDMEConfig) AddContactList(newContactList ContactList) (*ContactList, error) {
+	return dme.AddContactListContext(context.Background(), newContactList)
+}
+
+// AddContactListContext is the same as AddContactList(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) AddContactListContext(ctx context.Context, newContactList ContactList) (*ContactList, error) {
+	bodyData, err := json.Marshal(newContactList)
+	if err != nil {
+		return nil, err
+	}
+	bodyBuffer := bytes.NewReader(bodyData)
+	req, err := dme.newRequest(ctx, "POST", "contactList", bodyBuffer)
+	if err != nil {
+		return nil, err
+	}
+
+	returnedContactList := &ContactList{}
+	err = dme.doDMERequest(req, returnedContactList)
+	if err != nil {
+		return nil, err
+	}
+	return returnedContactList, err
+}
+
+// UpdateContactList updates an existing contact list (identified by its ID) for your account. DNS Made Easy only returns success/fail for this method.
+func (dme *GoDMEConfig) UpdateContactList(ContactList *ContactList) error {
+	return dme.UpdateContactListContext(context.Background(), ContactList)
+}
+
+// UpdateContactListContext is the same as UpdateContactList(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) UpdateContactListContext(ctx context.Context, ContactList *ContactList) error {
+	reqStub := fmt.Sprintf("contactList/%v", ContactList.ID)
+	bodyData, err := json.Marshal(ContactList)
+	if err != nil {
+		return err
+	}
+	return dme.genericUpdate(ctx, reqStub, bodyData)
+}
+
+// DeleteContactList deletes an existing contact list (identified by its ID). The contact list must not be in use before deleting.
+func (dme *GoDMEConfig) DeleteContactList(ContactListID int) error {
+	return dme.DeleteContactListContext(context.Background(), ContactListID)
+}
+
+// DeleteContactListContext is the same as DeleteContactList(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) DeleteContactListContext(ctx context.Context, ContactListID int) error {
+	return dme.genericDelete(ctx, fmt.Sprintf("contactList/%v", ContactListID), 0)
+}
+
+// SetFailoverContactList looks up a contact list by its name, and sets Failover.ContactListID to it. This only changes Failover, so
+// send it to DNS Made Easy with UpdateFailover() afterwards. If there is no such contact list, the error is a *NotFoundError.
+func (dme *GoDMEConfig) SetFailoverContactList(Failover *Failover, ContactListName string) error {
+	return dme.SetFailoverContactListContext(context.Background(), Failover, ContactListName)
+}
+
+// SetFailoverContactListContext is the same as SetFailoverContactList(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) SetFailoverContactListContext(ctx context.Context, Failover *Failover, ContactListName string) error {
+	thisContactList, err := dme.ContactListByNameContext(ctx, ContactListName)
+	if err != nil {
+		return err
+	}
+	Failover.ContactListID = thisContactList.ID
+	return nil
+}
@@ -0,0 +1,186 @@
+package GoDNSMadeEasy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// These are the classes of API failure that callers usually want to branch on. They are never returned directly, but an *APIError
+// matches them with errors.Is(), so errors.Is(err, ErrNotFound) works on anything returned from this package.
+var (
+	// ErrNotFound means the object (domain, record, SOA, etc) does not exist, or is not visible to this API key
+	ErrNotFound = errors.New("not found")
+	// ErrForbidden means DNS Made Easy rejected our credentials or the request signature (check TimeAdjust if this is intermittent)
+	ErrForbidden = errors.New("access forbidden")
+	// ErrPendingAction means the object has a create or delete in progress, and the request will probably succeed if tried again later
+	ErrPendingAction = errors.New("pending action")
+	// ErrRateLimited means the account has used up its request allowance, and the request should be tried again later
+	ErrRateLimited = errors.New("rate limited")
+	// ErrDuplicate means the object we tried to create already exists
+	ErrDuplicate = errors.New("already exists")
+)
+
+// APIError is returned when DNS Made Easy responds to a request with an error. It carries everything we know about the failed request,
+// so callers can use errors.As() to inspect it, or use errors.Is() with the Err* values (or the Is* helpers) to classify it.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response
+	StatusCode int
+	// Method is the HTTP method of the request (GET, POST, PUT, DELETE)
+	Method string
+	// Endpoint is the API endpoint of the request, relative to APIUrl (e.g. dns/managed/123456/records)
+	Endpoint string
+	// Body is the raw response body that DNS Made Easy sent back
+	Body []byte
+	// Messages are the error messages parsed from the response body (the "error" array), if there were any
+	Messages []string
+}
+
+func (e *APIError) Error() string {
+	if len(e.Messages) > 0 {
+		return strings.Join(e.Messages, "\n")
+	}
+	return fmt.Sprintf("%v %s (%s %s)", e.StatusCode, http.StatusText(e.StatusCode), e.Method, e.Endpoint)
+}
+
+// Is makes errors.Is() match an *APIError against ErrNotFound, ErrForbidden, ErrPendingAction, ErrRateLimited and ErrDuplicate
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrPendingAction:
+		return e.messageContains("pending")
+	case ErrRateLimited:
+		//DNS Made Easy sends a 400 with a "Rate limit exceeded" message, rather than a 429
+		return e.StatusCode == http.StatusTooManyRequests || e.messageContains("rate limit")
+	case ErrDuplicate:
+		return e.messageContains("already exists")
+	}
+	return false
+}
+
+// Case-insensitive check of the parsed error messages for a given string
+func (e *APIError) messageContains(s string) bool {
+	for _, msg := range e.Messages {
+		if strings.Contains(strings.ToLower(msg), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// NotFoundError is returned when looking up an object by name, and DNS Made Easy has nothing by that name. It matches ErrNotFound with errors.Is().
+type NotFoundError struct {
+	// Kind is the kind of object we looked for (e.g. "domain")
+	Kind string
+	// Name is the (normalised) name we looked for
+	Name string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s %s not found", e.Kind, e.Name)
+}
+
+// Is makes errors.Is() match a *NotFoundError against ErrNotFound
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// IsNotFound reports whether err is an API error for an object that does not exist
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsForbidden reports whether err is an API error for a request that DNS Made Easy refused to authorise
+func IsForbidden(err error) bool {
+	return errors.Is(err, ErrForbidden)
+}
+
+// IsPendingAction reports whether err is an API error caused by a create or delete that is still in progress
+func IsPendingAction(err error) bool {
+	return errors.Is(err, ErrPendingAction)
+}
+
+// IsRateLimited reports whether err is an API error caused by the account running out of requests
+func IsRateLimited(err error) bool {
+	return errors.Is(err, ErrRateLimited)
+}
+
+// IsDuplicate reports whether err is an API error caused by creating an object that already exists
+func IsDuplicate(err error) bool {
+	return errors.Is(err, ErrDuplicate)
+}
+
+// BulkError is returned by the bulk record methods (AddRecords, UpdateRecords) when one or more chunks of records could not be sent.
+// errors.Is() and errors.As() look through to the error from every failed chunk.
+type BulkError struct {
+	Chunks []BulkChunkError
+}
+
+// BulkChunkError is the failure of a single chunk of records in a bulk request
+type BulkChunkError struct {
+	// Offset is the position of the chunk's first record in the slice that was passed in
+	Offset int
+	// Records are the records in the chunk that failed
+	Records []Record
+	// Err is the error returned when sending the chunk
+	Err error
+}
+
+func (e *BulkError) Error() string {
+	var failedRecords int
+	for _, chunk := range e.Chunks {
+		failedRecords += len(chunk.Records)
+	}
+	msg := fmt.Sprintf("%v of the bulk request chunks failed (%v records)", len(e.Chunks), failedRecords)
+	for _, chunk := range e.Chunks {
+		msg += fmt.Sprintf("\nrecords %v-%v: %s", chunk.Offset, chunk.Offset+len(chunk.Records)-1, chunk.Err)
+	}
+	return msg
+}
+
+// Unwrap returns the error from each failed chunk
+func (e *BulkError) Unwrap() []error {
+	errs := make([]error, len(e.Chunks))
+	for i, chunk := range e.Chunks {
+		errs[i] = chunk.Err
+	}
+	return errs
+}
+
+// ExportError is returned by ExportAllDomainsWithOptions when one or more domains could not be exported. The domains that could be
+// exported are still returned alongside it. errors.Is() and errors.As() look through to the error from every failed domain.
+type ExportError struct {
+	// Domains is the error from each domain that failed, keyed by domain name
+	Domains map[string]error
+}
+
+func (e *ExportError) Error() string {
+	msg := fmt.Sprintf("%v domains could not be exported", len(e.Domains))
+	for _, domainName := range e.domainNames() {
+		msg += fmt.Sprintf("\n%s: %s", domainName, e.Domains[domainName])
+	}
+	return msg
+}
+
+// Unwrap returns the error from each failed domain
+func (e *ExportError) Unwrap() []error {
+	var errs []error
+	for _, domainName := range e.domainNames() {
+		errs = append(errs, e.Domains[domainName])
+	}
+	return errs
+}
+
+func (e *ExportError) domainNames() []string {
+	var names []string
+	for domainName := range e.Domains {
+		names = append(names, domainName)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,108 @@
+package GoDNSMadeEasy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// MonitorProtocol is the protocol DNS Made Easy uses to check that a record's IP addresses are up
+type MonitorProtocol int
+
+// These are the protocols supported by DNS Made Easy's system monitoring
+const (
+	MonitorTCP   MonitorProtocol = 1
+	MonitorUDP   MonitorProtocol = 2
+	MonitorHTTP  MonitorProtocol = 3
+	MonitorDNS   MonitorProtocol = 4
+	MonitorSMTP  MonitorProtocol = 5
+	MonitorHTTPS MonitorProtocol = 6
+)
+
+// These are the sensitivities supported by DNS Made Easy, which is how many checks must fail before an IP is considered down
+const (
+	SensitivityLow    = 8
+	SensitivityMedium = 5
+	SensitivityHigh   = 3
+)
+
+// maxFailoverIPs is how many IP addresses DNS Made Easy lets us put in a failover configuration
+const maxFailoverIPs = 5
+
+// IPs returns the failover IP addresses in order, skipping any empty slots
+func (f *Failover) IPs() []string {
+	var ips []string
+	for _, ip := range []string{f.IP1, f.IP2, f.IP3, f.IP4, f.IP5} {
+		if ip != "" {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+// SetIPs sets the failover IP addresses, in order of preference. DNS Made Easy allows up to 5.
+func (f *Failover) SetIPs(ips []string) error {
+	if len(ips) > maxFailoverIPs {
+		return fmt.Errorf("failover can have at most %v IP addresses, but %v were given", maxFailoverIPs, len(ips))
+	}
+	slots := []*string{&f.IP1, &f.IP2, &f.IP3, &f.IP4, &f.IP5}
+	for i, slot := range slots {
+		*slot = ""
+		if i < len(ips) {
+			*slot = ips[i]
+		}
+	}
+	return nil
+}
+
+// Failover returns the failover and system monitoring configuration for a record (identified by its ID)
+func (dme *GoDMEConfig) Failover(RecordID int) (*Failover, error) {
+	return dme.FailoverContext(context.Background(), RecordID)
+}
+
+// FailoverContext is the same as Failover(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) FailoverContext(ctx context.Context, RecordID int) (*Failover, error) {
+	reqStub := fmt.Sprintf("monitor/%v", RecordID)
+	req, err := dme.newRequest(ctx, "GET", reqStub, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	failoverResponse := &Failover{}
+	err = dme.doDMERequest(req, failoverResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return failoverResponse, nil
+}
+
+// UpdateFailover sets the failover and system monitoring configuration for the record identified by Failover.RecordID. DNS Made Easy only
+// returns success/fail for this method.
+func (dme *GoDMEConfig) UpdateFailover(Failover *Failover) error {
+	return dme.UpdateFailoverContext(context.Background(), Failover)
+}
+
+// UpdateFailoverContext is the same as UpdateFailover(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) UpdateFailoverContext(ctx context.Context, Failover *Failover) error {
+	reqStub := fmt.Sprintf("monitor/%v", Failover.RecordID)
+	bodyData, err := json.Marshal(Failover)
+	if err != nil {
+		return err
+	}
+	return dme.genericUpdate(ctx, reqStub, bodyData)
+}
+
+// DisableFailover turns off both failover and system monitoring for a record (identified by its ID)
+func (dme *GoDMEConfig) DisableFailover(RecordID int) error {
+	return dme.DisableFailoverContext(context.Background(), RecordID)
+}
+
+// DisableFailoverContext is the same as DisableFailover(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) DisableFailoverContext(ctx context.Context, RecordID int) error {
+	return dme.UpdateFailoverContext(ctx, &Failover{
+		RecordID: RecordID,
+		Monitor:  false,
+		Failover: false,
+	})
+}
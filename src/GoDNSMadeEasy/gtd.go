@@ -0,0 +1,112 @@
+package GoDNSMadeEasy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// GTDLocation is a Global Traffic Director region. Records with a GTD location are only served to DNS queries from that region, which
+// lets a domain with GTD enabled answer differently depending on where the query came from.
+type GTDLocation string
+
+// These are the GTD locations supported by DNS Made Easy. GTDDefault is served to every region that has no record of its own, and is
+// the only location that can be used on a domain without GTD enabled. They are untyped, so they can be used for Record.GtdLocation
+// (a string) as well as a GTDLocation.
+const (
+	GTDDefault      = "DEFAULT"
+	GTDUSEast       = "US_EAST"
+	GTDUSWest       = "US_WEST"
+	GTDEurope       = "EUROPE"
+	GTDAsiaPac      = "ASIA_PAC"
+	GTDOceania      = "OCEANIA"
+	GTDSouthAmerica = "SOUTH_AMERICA"
+)
+
+// GTDLocations is every GTD location, in the order DNS Made Easy lists them
+var GTDLocations = []GTDLocation{GTDDefault, GTDUSEast, GTDUSWest, GTDEurope, GTDAsiaPac, GTDOceania, GTDSouthAmerica}
+
+// ErrGTDNotEnabled is returned (wrapped) when adding or updating a record with a GTD location other than GTDDefault, in a domain that
+// does not have GTD enabled. Use EnableGTD() on the domain first.
+var ErrGTDNotEnabled = errors.New("GTD is not enabled")
+
+// Valid reports whether l is one of the GTD locations supported by DNS Made Easy. An empty location is treated as GTDDefault.
+func (l GTDLocation) Valid() bool {
+	if l == "" {
+		return true
+	}
+	for _, location := range GTDLocations {
+		if l == location {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDefault reports whether l is GTDDefault. DNS Made Easy treats an empty location the same as GTDDefault.
+func (l GTDLocation) IsDefault() bool {
+	return l == "" || l == GTDDefault
+}
+
+// EnableGTD turns on Global Traffic Director for a domain, so that its records can use GTD locations other than GTDDefault, and sets
+// Domain.GtdEnabled to match. If the update fails, Domain is left as it was.
+func (dme *GoDMEConfig) EnableGTD(Domain *Domain) error {
+	return dme.EnableGTDContext(context.Background(), Domain)
+}
+
+// EnableGTDContext is the same as EnableGTD(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) EnableGTDContext(ctx context.Context, Domain *Domain) error {
+	oldGtdEnabled := Domain.GtdEnabled
+	Domain.GtdEnabled = true
+	err := dme.UpdateDomainContext(ctx, Domain)
+	if err != nil {
+		Domain.GtdEnabled = oldGtdEnabled
+	}
+	return err
+}
+
+// RecordsByGTDLocation returns the records for a given domain, grouped by their GTD location. Records without a location are grouped
+// under GTDDefault.
+func (dme *GoDMEConfig) RecordsByGTDLocation(DomainID int) (map[GTDLocation][]Record, error) {
+	return dme.RecordsByGTDLocationContext(context.Background(), DomainID)
+}
+
+// RecordsByGTDLocationContext is the same as RecordsByGTDLocation(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) RecordsByGTDLocationContext(ctx context.Context, DomainID int) (map[GTDLocation][]Record, error) {
+	recordData := make(map[GTDLocation][]Record)
+	err := dme.EachRecordContext(ctx, DomainID, func(thisRecord Record) error {
+		location := GTDLocation(thisRecord.GtdLocation)
+		if location.IsDefault() {
+			location = GTDDefault
+		}
+		recordData[location] = append(recordData[location], thisRecord)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recordData, nil
+}
+
+// checkGTDLocations makes sure that the domain has GTD enabled if any of the records have a GTD location other than GTDDefault. The domain is only fetched if it needs to be, so records in the default location cost no extra requests.
+func (dme *GoDMEConfig) checkGTDLocations(ctx context.Context, DomainID int, Records ...Record) error {
+	var needsGTD *Record
+	for i, thisRecord := range Records {
+		if !GTDLocation(thisRecord.GtdLocation).IsDefault() {
+			needsGTD = &Records[i]
+			break
+		}
+	}
+	if needsGTD == nil {
+		return nil
+	}
+
+	thisDomain, err := dme.DomainContext(ctx, DomainID)
+	if err != nil {
+		return err
+	}
+	if !thisDomain.GtdEnabled {
+		return fmt.Errorf("record %s has GTD location %s, but domain %s: %w", needsGTD.Name, needsGTD.GtdLocation, thisDomain.Name, ErrGTDNotEnabled)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package GoDNSMadeEasy
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha1"
 	"crypto/tls"
@@ -20,7 +21,14 @@ const LIVEAPI = "https://api.dnsmadeeasy.com/V2.0/"
 // SANDBOXAPI is the URL to the DNS Made Easy sandbox (testing) API. To use this you will need an account on the Sandbox system (https://sandbox.dnsmadeeasy.com/)
 const SANDBOXAPI = "https://api.sandbox.dnsmadeeasy.com/V2.0/"
 
-const pendingDeleteError = "Cannot delete a domain that is pending a create or delete action."
+// DefaultPageSize is the number of rows requested in each page of a list (of domains, records, etc), unless GoDMEConfig.PageSize says otherwise
+const DefaultPageSize = 1000
+
+// DefaultBulkChunkSize is the number of records sent in each request by AddRecords and UpdateRecords, unless GoDMEConfig.BulkChunkSize says otherwise
+const DefaultBulkChunkSize = 100
+
+// DefaultExportConcurrency is the number of domains exported at once by ExportAllDomains, unless ExportOptions.Concurrency says otherwise
+const DefaultExportConcurrency = 4
 
 // GoDMEConfig is our struct that contains our API settings, client, etc
 type GoDMEConfig struct {
@@ -36,7 +44,35 @@ type GoDMEConfig struct {
 	// and send a real timestamp, but DNS Made Easy has very strict requirements around time synchronisation. So if you're unlucky and your system time is a
 	// touch fast or slow, you can adjust the timestamp we send using TimeAdjust to make it more accurate to UTC.
 	TimeAdjust time.Duration
-	dmeClient  *http.Client
+	// RateLimitBehaviour controls whether requests are held back as the account's request allowance runs out. By default they are not,
+	// and DNS Made Easy will reject requests once the allowance is used up. The current allowance is available from RateLimit().
+	RateLimitBehaviour RateLimitBehaviour
+	// RateLimitReserve is the number of requests to leave unused when RateLimitBehaviour is RateLimitBlock or RateLimitPace. This
+	// leaves room for other tools that share the same account.
+	RateLimitReserve int
+	// RateLimitWindow is how long DNS Made Easy takes to give back the request allowance. If omitted, this defaults to DefaultRateLimitWindow
+	RateLimitWindow time.Duration
+	// Retry controls how requests that fail for temporary reasons (server errors, dropped connections, rate limiting, pending actions)
+	// are retried. By default they are not. See DefaultRetryPolicy() for a sensible starting point.
+	Retry RetryPolicy
+	// PageSize is the number of rows to request in each page when listing domains, records, etc. Every page is always fetched, so this
+	// only changes how many requests are made. If omitted, this defaults to DefaultPageSize
+	PageSize int
+	// BulkChunkSize is the most records that AddRecords and UpdateRecords will send in one request. Bigger batches are split into chunks
+	// of this size. If omitted, this defaults to DefaultBulkChunkSize
+	BulkChunkSize int
+	// PendingActionPollInterval is how often WaitForDomain and WaitForSecondaryDomain check whether a domain is ready. If omitted, this
+	// defaults to DefaultPendingActionPollInterval
+	PendingActionPollInterval time.Duration
+	// CreateWaitTimeout makes AddDomain and AddSecondaryDomain wait up to this long for the new domain to be ready before returning it.
+	// By default they return straight away, while DNS Made Easy is still setting the domain up.
+	CreateWaitTimeout time.Duration
+	// MaxDeletePercent is the largest share of a domain's records that Apply will delete, as a safety net against plans made from
+	// incomplete records. Set it to 100 to allow everything to be deleted, or to NoDeletes (or any negative number) to stop Apply
+	// deleting anything. If omitted (0), this defaults to DefaultMaxDeletePercent
+	MaxDeletePercent int
+	dmeClient        *http.Client
+	rateLimiter      *rateLimiter
 }
 
 // NewGoDNSMadeEasy must be called to construct a GoDMEConfig struct, otherwise there are uninitialised fields that may stop the API from working as expected
@@ -67,17 +103,32 @@ func NewGoDNSMadeEasy(dme *GoDMEConfig) (*GoDMEConfig, error) {
 
 	//Assign that transport to our new HTTP client (which we will reuse for all of the API requests)
 	dme.dmeClient = &http.Client{Transport: tr}
+	dme.rateLimiter = &rateLimiter{}
 
 	return dme, nil
 }
 
-func (dme *GoDMEConfig) newRequest(Method, APIEndpoint string, body io.Reader) (*http.Request, error) {
+func (dme *GoDMEConfig) newRequest(ctx context.Context, Method, APIEndpoint string, body io.Reader) (*http.Request, error) {
 	//Double check we have an API endpoint, just in case someone decides to create this object manually instead of
 	//using NewGoDNSMadeEasy, or they screw around with it after it's created
 	if dme.APIUrl == "" {
 		dme.APIUrl = LIVEAPI
 	}
 
+	thisRequestURI := dme.APIUrl + APIEndpoint
+	thisReq, err := http.NewRequestWithContext(ctx, Method, thisRequestURI, body)
+	if err != nil {
+		return nil, err
+	}
+	thisReq.Header.Set("x-dnsme-apiKey", dme.APIKey)
+	thisReq.Header.Set("accept", "application/json")
+
+	return thisReq, nil
+}
+
+// signRequest sets the time-based authentication headers on a request. The signature is only valid for a short time, so this is
+// done again right before every attempt at sending a request.
+func (dme *GoDMEConfig) signRequest(req *http.Request) {
 	//Generate our Hex encoded HMAC SHA1 signature of the current date/time in UTC for our requests
 	timeNow := time.Now().UTC()
 	timeNow = timeNow.Add(dme.TimeAdjust)
@@ -87,25 +138,54 @@ func (dme *GoDMEConfig) newRequest(Method, APIEndpoint string, body io.Reader) (
 	h.Write([]byte(timeNowString))
 	hmacSha := hex.EncodeToString(h.Sum(nil))
 
-	thisRequestURI := dme.APIUrl + APIEndpoint
-	thisReq, err := http.NewRequest(Method, thisRequestURI, body)
-	if err != nil {
-		return nil, err
-	}
-	thisReq.Header.Set("x-dnsme-apiKey", dme.APIKey)
-	thisReq.Header.Set("x-dnsme-requestDate", timeNowString)
-	thisReq.Header.Set("x-dnsme-hmac", hmacSha)
-	thisReq.Header.Set("accept", "application/json")
-
-	return thisReq, nil
+	req.Header.Set("x-dnsme-requestDate", timeNowString)
+	req.Header.Set("x-dnsme-hmac", hmacSha)
 }
 
+// doDMERequest sends a request to DNS Made Easy and decodes the response into dst. Failed attempts are retried according to the
+// Retry policy, with the request body re-sent and the request signed again each time.
 func (dme *GoDMEConfig) doDMERequest(req *http.Request, dst interface{}) error {
+	ctx := req.Context()
+	thisReq := req
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			thisReq = req.Clone(ctx)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return err
+				}
+				thisReq.Body = body
+			}
+		}
+
+		err := dme.sendDMERequest(thisReq, dst)
+		if err == nil || attempt >= dme.Retry.MaxAttempts || ctx.Err() != nil || !dme.Retry.retryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(dme.Retry.backoff(attempt)):
+		}
+	}
+}
+
+// sendDMERequest makes a single attempt at sending a request to DNS Made Easy
+func (dme *GoDMEConfig) sendDMERequest(req *http.Request, dst interface{}) error {
+	err := dme.waitForRateLimit(req.Context())
+	if err != nil {
+		return err
+	}
+	//Sign the request now, in case we had to wait around for the rate limit
+	dme.signRequest(req)
 	resp, err := dme.dmeClient.Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
+	dme.updateRateLimit(resp)
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return err
@@ -116,20 +196,19 @@ func (dme *GoDMEConfig) doDMERequest(req *http.Request, dst interface{}) error {
 		body = []byte(strings.Replace(string(body), "{error:", "{\"error\":", 1))
 	}
 
-	if resp.StatusCode == http.StatusForbidden {
-		return fmt.Errorf("Access forbidden (%s)", req.URL.String())
-	}
-	if resp.StatusCode == http.StatusNotFound {
-		return fmt.Errorf("404 Not Found (%s)", req.URL.String())
-	}
-
 	//fmt.Println(string(body))
 	genericError := &GenericError{}
 
 	//Try to unmarshal into an error to see if we get any data. A successful delete or update sends no body, so it might throw an error for DELETE or PUT, but that's OK
 	json.Unmarshal(body, genericError)
-	if len(genericError.Error) > 0 {
-		return fmt.Errorf(strings.Join(genericError.Error, "\n"))
+	if resp.StatusCode >= http.StatusBadRequest || len(genericError.Error) > 0 {
+		return &APIError{
+			StatusCode: resp.StatusCode,
+			Method:     req.Method,
+			Endpoint:   strings.TrimPrefix(req.URL.String(), dme.APIUrl),
+			Body:       body,
+			Messages:   genericError.Error,
+		}
 	}
 
 	//If we are deleting a record and got this far, then it's been successful
@@ -183,27 +262,79 @@ type NameServer struct {
 
 // Record represents a DNS record from DNS Made Easy (e.g. A, AAAA, PTR, NS, etc)
 type Record struct {
-	Name         string `json:"name"`
-	Value        string `json:"value"`
-	ID           int    `json:"id"`
-	Type         string `json:"type"`
-	DynamicDNS   bool   `json:"dynamicDns"`
-	Failed       bool   `json:"failed"`
-	GtdLocation  string `json:"gtdLocation"`
-	HardLink     bool   `json:"hardLink"`
-	TTL          int    `json:"ttl"`
-	Failover     bool   `json:"failover"`
-	Monitor      bool   `json:"monitor"`
-	SourceID     int    `json:"sourceId"`
-	Source       int    `json:"source"`
-	MxLevel      int    `json:"mxLevel,omitempty"`
-	Priority     int    `json:"priority,omitempty"`
-	Port         int    `json:"port,omitempty"`
-	Weight       int    `json:"weight,omitempty"`
-	Keywords     string `json:"keywords,omitempty"`
-	RedirectType string `json:"redirectType,omitempty"`
-	Title        string `json:"title,omitempty"`
-	Description  string `json:"description,omitempty"`
+	Name           string `json:"name"`
+	Value          string `json:"value"`
+	ID             int    `json:"id"`
+	Type           string `json:"type"`
+	DynamicDNS     bool   `json:"dynamicDns"`
+	Failed         bool   `json:"failed"`
+	GtdLocation    string `json:"gtdLocation"`
+	HardLink       bool   `json:"hardLink"`
+	TTL            int    `json:"ttl"`
+	Failover       bool   `json:"failover"`
+	Monitor        bool   `json:"monitor"`
+	SourceID       int    `json:"sourceId"`
+	Source         int    `json:"source"`
+	MxLevel        int    `json:"mxLevel,omitempty"`
+	Priority       int    `json:"priority,omitempty"`
+	Port           int    `json:"port,omitempty"`
+	Weight         int    `json:"weight,omitempty"`
+	Keywords       string `json:"keywords,omitempty"`
+	RedirectType   string `json:"redirectType,omitempty"`
+	Title          string `json:"title,omitempty"`
+	Description    string `json:"description,omitempty"`
+	CaaType        string `json:"caaType,omitempty"`
+	IssuerCritical int    `json:"issuerCritical,omitempty"`
+}
+
+// Failover is the failover and system monitoring configuration for a record. Monitor turns on checking of the IP addresses (and
+// notifications to the contact list), and Failover moves the record's value to the next IP address when the current one is down.
+type Failover struct {
+	RecordID          int             `json:"recordId"`
+	Monitor           bool            `json:"monitor"`
+	Failover          bool            `json:"failover"`
+	AutoFailover      bool            `json:"autoFailover"`
+	Protocol          MonitorProtocol `json:"protocolId,omitempty"`
+	Port              int             `json:"port,omitempty"`
+	Sensitivity       int             `json:"sensitivity,omitempty"`
+	IP1               string          `json:"ip1,omitempty"`
+	IP2               string          `json:"ip2,omitempty"`
+	IP3               string          `json:"ip3,omitempty"`
+	IP4               string          `json:"ip4,omitempty"`
+	IP5               string          `json:"ip5,omitempty"`
+	ContactListID     int             `json:"contactListId,omitempty"`
+	MaxEmails         int             `json:"maxEmails,omitempty"`
+	SystemDescription string          `json:"systemDescription,omitempty"`
+	HTTPFqdn          string          `json:"httpFqdn,omitempty"`
+	HTTPFile          string          `json:"httpFile,omitempty"`
+	HTTPQueryString   string          `json:"httpQueryString,omitempty"`
+	SourceID          int             `json:"sourceId,omitempty"`
+	Source            int             `json:"source,omitempty"`
+}
+
+// ContactList is a DNS Made Easy contact list, the email addresses that are notified when system monitoring or failover sees an IP go down
+type ContactList struct {
+	Name   string   `json:"name"`
+	ID     int      `json:"id"`
+	Emails []string `json:"emails"`
+}
+
+// These are the values of QueryUsage.PrimaryEntity, which say whether the usage is for a primary or a secondary domain
+const (
+	UsageEntityDomain    = "domain"
+	UsageEntitySecondary = "secondary"
+)
+
+// QueryUsage is the number of DNS queries DNS Made Easy answered in a month, either for the whole account or for a single domain
+type QueryUsage struct {
+	Total             int64  `json:"total"`
+	Month             int    `json:"month"`
+	Year              int    `json:"year"`
+	AccountID         int    `json:"accountId,omitempty"`
+	PrimaryEntity     string `json:"primaryEntity,omitempty"`
+	PrimaryEntityID   int    `json:"primaryEntityId,omitempty"`
+	SecondaryEntity   string `json:"secondaryEntity,omitempty"`
+	SecondaryEntityID int    `json:"secondaryEntityId,omitempty"`
 }
 
 // SOA represents a Start of Authority configuration from DNS Made Easy
@@ -238,6 +369,21 @@ type IPSet struct {
 	Ips  []string `json:"ips"`
 }
 
+// TransferACL is a DNS Made Easy transfer ACL, which lists the servers allowed to do zone transfers (AXFR) of the domains it is assigned to
+type TransferACL struct {
+	Name string   `json:"name"`
+	ID   int      `json:"id"`
+	Ips  []string `json:"ips"`
+}
+
+// Template is a DNS Made Easy record template, a standard set of records that can be applied to many domains (see Domain.TemplateID)
+type Template struct {
+	Name           string `json:"name"`
+	ID             int    `json:"id"`
+	DomainIDs      []int  `json:"domainIds,omitempty"`
+	PublicTemplate bool   `json:"publicTemplate"`
+}
+
 // SecondaryDomain is the configuration for a secondary DNS domain
 type SecondaryDomain struct {
 	Name              string       `json:"name"`
@@ -255,10 +401,27 @@ type SecondaryDomain struct {
 
 // DomainExport is all of the data about a given domain that we can get from DNS Made Easy
 type DomainExport struct {
-	SOA       *SOA
-	Info      *Domain
-	DefaultNS *Vanity
-	Records   *[]Record
+	SOA         *SOA
+	Info        *Domain
+	DefaultNS   *Vanity
+	TransferACL *TransferACL
+	Records     *[]Record
+	// Failover is the failover configuration of each record that has monitoring or failover turned on, keyed by record ID. This is only
+	// filled in when ExportOptions.IncludeFailover is set.
+	Failover map[int]*Failover
+}
+
+// ExportOptions changes what ExportAllDomainsWithOptions() includes in its export
+type ExportOptions struct {
+	// IncludeFailover fetches the failover configuration of every record that has monitoring or failover turned on. This takes one
+	// extra request per record, so is off by default.
+	IncludeFailover bool
+	// Concurrency is the number of domains whose records are fetched at once. Every request still goes through the rate limit (see
+	// RateLimitBehaviour), so more only helps while the account has requests to spare. If omitted, this defaults to DefaultExportConcurrency
+	Concurrency int
+	// Progress, if set, is called each time a domain has been exported (or has failed), with the number of domains finished so far and
+	// the total. It is only called by one goroutine at a time.
+	Progress func(Done, Total int, DomainName string)
 }
 
 // Folder is a DNS Made Easy folder, used in the list of folders.
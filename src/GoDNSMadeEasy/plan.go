@@ -0,0 +1,323 @@
+package GoDNSMadeEasy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultMaxDeletePercent is the largest share of a domain's records that Apply will delete, unless GoDMEConfig.MaxDeletePercent says otherwise
+const DefaultMaxDeletePercent = 25
+
+// NoDeletes is the value of GoDMEConfig.MaxDeletePercent that stops Apply from deleting any records. Any negative value does the same.
+const NoDeletes = -1
+
+// ErrUnsafePlan is returned (wrapped) by Apply when a plan would delete more of a domain's records than MaxDeletePercent allows. This
+// usually means the desired records are incomplete, such as a config file that failed to load properly.
+var ErrUnsafePlan = errors.New("unsafe plan")
+
+// RecordUpdate is a record in a Plan that needs changing. Old is the record as it is now, and New is how it should be (with Old's ID).
+type RecordUpdate struct {
+	Old Record
+	New Record
+}
+
+// Plan is the set of changes that turns a domain's records into the desired records. Make one with Diff() or PlanRecords(), check it
+// (String() describes it for people), then make the changes with Apply().
+type Plan struct {
+	Creates []Record
+	Updates []RecordUpdate
+	Deletes []Record
+	// Unchanged is the number of records that already match what is desired
+	Unchanged int
+}
+
+// Empty reports whether the plan has no changes to make
+func (p *Plan) Empty() bool {
+	return len(p.Creates) == 0 && len(p.Updates) == 0 && len(p.Deletes) == 0
+}
+
+// String describes the plan for people, with one line per change in the order Apply makes them: deletes (-), updates (~), then creates (+)
+func (p *Plan) String() string {
+	plan := &strings.Builder{}
+	fmt.Fprintf(plan, "Plan: %v to create, %v to update, %v to delete, %v unchanged\n", len(p.Creates), len(p.Updates), len(p.Deletes), p.Unchanged)
+	for _, thisRecord := range p.Deletes {
+		fmt.Fprintf(plan, "- %s\n", planLine(thisRecord))
+	}
+	for _, thisUpdate := range p.Updates {
+		fmt.Fprintf(plan, "~ %s (%s)\n", planLine(thisUpdate.New), strings.Join(recordChanges(thisUpdate.Old, thisUpdate.New), ", "))
+	}
+	for _, thisRecord := range p.Creates {
+		fmt.Fprintf(plan, "+ %s\n", planLine(thisRecord))
+	}
+	return plan.String()
+}
+
+// planLine writes a record the same way as a master file, so that plans are easy to read for anyone used to zone files
+func planLine(r Record) string {
+	name := r.Name
+	if name == "" {
+		name = "@"
+	}
+	line := fmt.Sprintf("%s\t%v\tIN\t%s\t%s", name, r.TTL, r.Type, recordData(r))
+	if !GTDLocation(r.GtdLocation).IsDefault() {
+		line += fmt.Sprintf("\t; GTD location %s", r.GtdLocation)
+	}
+	return line
+}
+
+// Diff works out the changes needed to turn the Current records of a domain into the Desired ones. Desired records with the ID of a
+// current record are always matched to that record. The rest are matched by name, type, GTD location and value, so a name can have
+// several records of the same type (such as a set of A records). Anything left over with the same name, type and GTD location as a
+// current record is an update to that record's value, and the rest are creates and deletes.
+//
+// Names are compared without regard to case, as are values that are hostnames. TXT and SPF values are compared by their text, so
+// quoting doesn't matter. Failover and monitoring are not part of the plan.
+func Diff(Current, Desired []Record) *Plan {
+	plan := &Plan{}
+	matched := make([]bool, len(Current))
+
+	//First, desired records that are already tied to a current record by ID
+	currentByID := map[int]int{}
+	for i, thisRecord := range Current {
+		if thisRecord.ID != 0 {
+			currentByID[thisRecord.ID] = i
+		}
+	}
+	var byValue []Record
+	for _, want := range Desired {
+		if i, ok := currentByID[want.ID]; ok && want.ID != 0 && !matched[i] {
+			matched[i] = true
+			plan.pair(Current[i], want)
+			continue
+		}
+		byValue = append(byValue, want)
+	}
+
+	//Then the ones with a current record with the same value, and then the ones with a current record in the same set
+	bySet := plan.match(Current, matched, byValue, true)
+	creates := plan.match(Current, matched, bySet, false)
+
+	for _, want := range creates {
+		want.ID = 0
+		plan.Creates = append(plan.Creates, want)
+	}
+	for i, thisRecord := range Current {
+		if !matched[i] {
+			plan.Deletes = append(plan.Deletes, thisRecord)
+		}
+	}
+
+	//Keep each kind of change in name order, so that plans are easy to read
+	sortRecords(plan.Creates)
+	sortRecords(plan.Deletes)
+	sort.SliceStable(plan.Updates, func(i, j int) bool {
+		return recordLess(plan.Updates[i].New, plan.Updates[j].New)
+	})
+	return plan
+}
+
+func sortRecords(Records []Record) {
+	sort.SliceStable(Records, func(i, j int) bool {
+		return recordLess(Records[i], Records[j])
+	})
+}
+
+func recordLess(a, b Record) bool {
+	if !strings.EqualFold(a.Name, b.Name) {
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	}
+	return a.Type < b.Type
+}
+
+// match pairs up desired records with unmatched current records that have the same key, in order. It returns the desired records that
+// couldn't be matched.
+func (p *Plan) match(Current []Record, Matched []bool, Desired []Record, WithValue bool) []Record {
+	currentByKey := map[string][]int{}
+	for i, thisRecord := range Current {
+		if !Matched[i] {
+			key := recordKey(thisRecord, WithValue)
+			currentByKey[key] = append(currentByKey[key], i)
+		}
+	}
+
+	var unmatched []Record
+	for _, want := range Desired {
+		key := recordKey(want, WithValue)
+		if len(currentByKey[key]) == 0 {
+			unmatched = append(unmatched, want)
+			continue
+		}
+		i := currentByKey[key][0]
+		currentByKey[key] = currentByKey[key][1:]
+		Matched[i] = true
+		p.pair(Current[i], want)
+	}
+	return unmatched
+}
+
+// pair adds a desired record that has been matched to a current one, as either an update or an unchanged record
+func (p *Plan) pair(Old, New Record) {
+	New.ID = Old.ID
+	if len(recordChanges(Old, New)) == 0 {
+		p.Unchanged++
+		return
+	}
+	p.Updates = append(p.Updates, RecordUpdate{Old: Old, New: New})
+}
+
+// recordKey identifies the set a record belongs to (its name, type and GTD location), and optionally its value within that set
+func recordKey(r Record, WithValue bool) string {
+	key := fmt.Sprintf("%s\x00%s\x00%s", strings.ToLower(r.Name), r.Type, gtdKey(r.GtdLocation))
+	if WithValue {
+		key += "\x00" + recordValue(r)
+	}
+	return key
+}
+
+// An empty GTD location is the same as the default one
+func gtdKey(l string) string {
+	if GTDLocation(l).IsDefault() {
+		return GTDDefault
+	}
+	return l
+}
+
+// recordValue is a record's value, normalised so that values that mean the same thing compare as equal
+func recordValue(r Record) string {
+	switch r.Type {
+	case RecordCNAME, RecordANAME, RecordNS, RecordPTR, RecordMX, RecordSRV:
+		return strings.ToLower(r.Value)
+	case RecordTXT, RecordSPF:
+		if text, err := r.Text(); err == nil {
+			return text
+		}
+	}
+	return r.Value
+}
+
+// recordChanges describes the differences between two versions of a record, or returns nothing if they are the same
+func recordChanges(Old, New Record) []string {
+	var changes []string
+	changed := func(field string, old, new interface{}) {
+		if old != new {
+			changes = append(changes, fmt.Sprintf("%s %v -> %v", field, old, new))
+		}
+	}
+
+	if !strings.EqualFold(Old.Name, New.Name) {
+		changes = append(changes, fmt.Sprintf("name %q -> %q", Old.Name, New.Name))
+	}
+	changed("type", Old.Type, New.Type)
+	changed("GTD location", gtdKey(Old.GtdLocation), gtdKey(New.GtdLocation))
+	if recordValue(Old) != recordValue(New) {
+		changes = append(changes, fmt.Sprintf("value %s -> %s", Old.Value, New.Value))
+	}
+	changed("ttl", Old.TTL, New.TTL)
+	changed("mx level", Old.MxLevel, New.MxLevel)
+	changed("priority", Old.Priority, New.Priority)
+	changed("weight", Old.Weight, New.Weight)
+	changed("port", Old.Port, New.Port)
+	changed("caa tag", strings.ToLower(Old.CaaType), strings.ToLower(New.CaaType))
+	changed("caa flags", Old.IssuerCritical, New.IssuerCritical)
+	changed("redirect type", strings.ToLower(Old.RedirectType), strings.ToLower(New.RedirectType))
+	//DNS Made Easy sometimes sets hardLink on other types of record, where it means nothing
+	if New.Type == RecordHTTPRED {
+		changed("hard link", Old.HardLink, New.HardLink)
+	}
+	changed("title", Old.Title, New.Title)
+	changed("keywords", Old.Keywords, New.Keywords)
+	changed("description", Old.Description, New.Description)
+	changed("dynamic dns", Old.DynamicDNS, New.DynamicDNS)
+	return changes
+}
+
+// PlanRecords fetches the records of a domain (identified by its ID), and works out the changes needed to turn them into the Desired
+// records (see Diff)
+func (dme *GoDMEConfig) PlanRecords(DomainID int, Desired []Record) (*Plan, error) {
+	return dme.PlanRecordsContext(context.Background(), DomainID, Desired)
+}
+
+// PlanRecordsContext is the same as PlanRecords(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) PlanRecordsContext(ctx context.Context, DomainID int, Desired []Record) (*Plan, error) {
+	currentRecords, err := dme.RecordsContext(ctx, DomainID)
+	if err != nil {
+		return nil, err
+	}
+	return Diff(currentRecords, Desired), nil
+}
+
+// Apply makes the changes in a plan to a domain (identified by its ID). Records are deleted first, so that a name can change from one
+// type to another (such as A to CNAME), then updated, then created.
+//
+// Nothing is changed if the plan would delete more than MaxDeletePercent of the domain's records, or would delete anything when
+// MaxDeletePercent is NoDeletes (ErrUnsafePlan), or if any of the records are invalid. Otherwise Apply stops at the first change that
+// fails, and the changes before it stay made. Use PlanRecords() again to see what is left to do.
+func (dme *GoDMEConfig) Apply(DomainID int, Plan *Plan) error {
+	return dme.ApplyContext(context.Background(), DomainID, Plan)
+}
+
+// ApplyContext is the same as Apply(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) ApplyContext(ctx context.Context, DomainID int, Plan *Plan) error {
+	maxDeletePercent := dme.MaxDeletePercent
+	if maxDeletePercent == 0 {
+		maxDeletePercent = DefaultMaxDeletePercent
+	}
+	currentCount := len(Plan.Deletes) + len(Plan.Updates) + Plan.Unchanged
+	if maxDeletePercent < 0 && len(Plan.Deletes) > 0 {
+		return fmt.Errorf("plan deletes %v records, but deletes are turned off: %w", len(Plan.Deletes), ErrUnsafePlan)
+	}
+	if maxDeletePercent > 0 && len(Plan.Deletes)*100 > maxDeletePercent*currentCount {
+		return fmt.Errorf("plan deletes %v of %v records, which is more than %v%%: %w", len(Plan.Deletes), currentCount, maxDeletePercent, ErrUnsafePlan)
+	}
+
+	//Check everything before making any changes, so we don't stop half way through for a mistake we could have spotted
+	for _, thisUpdate := range Plan.Updates {
+		if err := thisUpdate.New.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, thisRecord := range Plan.Creates {
+		if err := thisRecord.Validate(); err != nil {
+			return err
+		}
+	}
+
+	chunkSize := dme.BulkChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultBulkChunkSize
+	}
+	var deleteIDs []int
+	for _, thisRecord := range Plan.Deletes {
+		deleteIDs = append(deleteIDs, thisRecord.ID)
+	}
+	for offset := 0; offset < len(deleteIDs); offset += chunkSize {
+		end := offset + chunkSize
+		if end > len(deleteIDs) {
+			end = len(deleteIDs)
+		}
+		err := dme.DeleteRecordsContext(ctx, DomainID, deleteIDs[offset:end])
+		if err != nil {
+			return fmt.Errorf("deleting records: %w", err)
+		}
+	}
+
+	for _, thisUpdate := range Plan.Updates {
+		newRecord := thisUpdate.New
+		err := dme.UpdateRecordContext(ctx, DomainID, &newRecord)
+		if err != nil {
+			return fmt.Errorf("updating %s record %q: %w", newRecord.Type, newRecord.Name, err)
+		}
+	}
+
+	for _, thisRecord := range Plan.Creates {
+		newRecord := thisRecord
+		_, err := dme.AddRecordContext(ctx, DomainID, &newRecord)
+		if err != nil {
+			return fmt.Errorf("creating %s record %q: %w", newRecord.Type, newRecord.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package GoDNSMadeEasy
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultRateLimitWindow is how long DNS Made Easy takes to give back the request allowance. At the time of writing, accounts get 150
+// requests in any 5 minute window.
+const DefaultRateLimitWindow = 5 * time.Minute
+
+// RateLimitBehaviour controls what the client does as the account's request allowance runs out
+type RateLimitBehaviour int
+
+const (
+	// RateLimitIgnore sends requests as fast as they are made, and leaves DNS Made Easy to reject them once the allowance is gone. This is the default.
+	RateLimitIgnore RateLimitBehaviour = iota
+	// RateLimitBlock sends requests as fast as they are made until only RateLimitReserve requests are left, then blocks until the
+	// rate limit window has passed.
+	RateLimitBlock
+	// RateLimitPace spreads the remaining requests evenly over the rate limit window, so that long running jobs (such as
+	// ExportAllDomains on a big account) never run out. It blocks the same as RateLimitBlock if only RateLimitReserve requests are left.
+	RateLimitPace
+)
+
+// RateLimit is the request allowance for the account, as last reported by DNS Made Easy in the x-dnsme-requestLimit and
+// x-dnsme-requestsRemaining response headers.
+type RateLimit struct {
+	// Limit is the total number of requests allowed in the rate limit window
+	Limit int
+	// Remaining is the number of requests left in the rate limit window
+	Remaining int
+	// Updated is when DNS Made Easy last told us about the rate limit. This is the zero time if no requests have been made yet.
+	Updated time.Time
+}
+
+// RateLimit returns the request allowance for the account, as reported by the last response from DNS Made Easy. Requests that
+// have been sent but not yet answered are already taken off Remaining.
+func (dme *GoDMEConfig) RateLimit() RateLimit {
+	if dme.rateLimiter == nil {
+		return RateLimit{}
+	}
+	dme.rateLimiter.mu.Lock()
+	defer dme.rateLimiter.mu.Unlock()
+	return dme.rateLimiter.state
+}
+
+// rateLimiter keeps track of the allowance reported by DNS Made Easy, and is shared by every request made with a GoDMEConfig
+type rateLimiter struct {
+	mu    sync.Mutex
+	state RateLimit
+	//The earliest time the next request can be sent when pacing requests
+	next time.Time
+}
+
+// waitForRateLimit blocks until a request can be sent under the configured RateLimitBehaviour, or until ctx is done
+func (dme *GoDMEConfig) waitForRateLimit(ctx context.Context) error {
+	if dme.rateLimiter == nil || dme.RateLimitBehaviour == RateLimitIgnore {
+		return nil
+	}
+
+	window := dme.RateLimitWindow
+	if window <= 0 {
+		window = DefaultRateLimitWindow
+	}
+
+	rl := dme.rateLimiter
+	for {
+		rl.mu.Lock()
+		now := time.Now()
+		delay := time.Duration(0)
+
+		//Nothing to go on until the first response comes back
+		if !rl.state.Updated.IsZero() {
+			if rl.state.Remaining <= dme.RateLimitReserve {
+				//Out of requests, so wait for the window to roll over. Once it has, assume we have the full allowance back
+				//until the next response tells us otherwise.
+				resetAt := rl.state.Updated.Add(window)
+				if now.Before(resetAt) {
+					delay = resetAt.Sub(now)
+				} else {
+					rl.state.Remaining = rl.state.Limit
+					rl.state.Updated = now
+				}
+			} else if dme.RateLimitBehaviour == RateLimitPace && now.Before(rl.next) {
+				delay = rl.next.Sub(now)
+			}
+		}
+
+		if delay <= 0 {
+			//Take this request off the allowance now, so other goroutines sharing the client don't all rush in at once
+			if !rl.state.Updated.IsZero() {
+				rl.state.Remaining--
+				if dme.RateLimitBehaviour == RateLimitPace {
+					spare := rl.state.Remaining - dme.RateLimitReserve
+					if spare < 1 {
+						spare = 1
+					}
+					rl.next = now.Add(window / time.Duration(spare))
+				}
+			}
+			rl.mu.Unlock()
+			return nil
+		}
+		rl.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// updateRateLimit records the allowance headers from a DNS Made Easy response. Responses without the headers are ignored.
+func (dme *GoDMEConfig) updateRateLimit(resp *http.Response) {
+	if dme.rateLimiter == nil {
+		return
+	}
+	limit, err := strconv.Atoi(resp.Header.Get("x-dnsme-requestLimit"))
+	if err != nil {
+		return
+	}
+	remaining, err := strconv.Atoi(resp.Header.Get("x-dnsme-requestsRemaining"))
+	if err != nil {
+		return
+	}
+
+	dme.rateLimiter.mu.Lock()
+	defer dme.rateLimiter.mu.Unlock()
+	dme.rateLimiter.state = RateLimit{
+		Limit:     limit,
+		Remaining: remaining,
+		Updated:   time.Now(),
+	}
+}
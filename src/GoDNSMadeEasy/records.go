@@ -0,0 +1,221 @@
+package GoDNSMadeEasy
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// MXData is the type-specific data of an MX record
+type MXData struct {
+	// Host is the mail server, either relative to the domain or fully qualified with a trailing dot
+	Host string
+	// Level is the MX preference. Lower levels are tried first.
+	Level int
+}
+
+// SRVData is the type-specific data of an SRV record
+type SRVData struct {
+	// Target is the host providing the service, either relative to the domain or fully qualified with a trailing dot
+	Target   string
+	Priority int
+	Weight   int
+	Port     int
+}
+
+// CAAData is the type-specific data of a CAA record
+type CAAData struct {
+	// Critical means certificate authorities must understand Tag to issue a certificate
+	Critical bool
+	// Tag is CAAIssue, CAAIssueWild or CAAIodef
+	Tag   string
+	Value string
+}
+
+// HTTPRedirect is the type-specific data of an HTTPRED record, which DNS Made Easy serves as an HTTP redirect to URL
+type HTTPRedirect struct {
+	URL string
+	// RedirectType is RedirectHidden, RedirectStandard or RedirectPermanent
+	RedirectType string
+	// HardLink keeps the rest of the requested path when redirecting, rather than always sending visitors to URL itself
+	HardLink bool
+	// Title, Keywords and Description are put in the page of a RedirectHidden redirect
+	Title       string
+	Keywords    string
+	Description string
+}
+
+// NewARecord returns an A record pointing Name at an IPv4 address
+func NewARecord(Name, IP string, TTL int) *Record {
+	return newRecord(RecordA, Name, IP, TTL)
+}
+
+// NewAAAARecord returns an AAAA record pointing Name at an IPv6 address
+func NewAAAARecord(Name, IP string, TTL int) *Record {
+	return newRecord(RecordAAAA, Name, IP, TTL)
+}
+
+// NewCNAMERecord returns a CNAME record making Name an alias of Target
+func NewCNAMERecord(Name, Target string, TTL int) *Record {
+	return newRecord(RecordCNAME, Name, Target, TTL)
+}
+
+// NewANAMERecord returns an ANAME record, which DNS Made Easy answers with the A records of Target. Unlike a CNAME, this can be used
+// for the domain itself (an empty Name).
+func NewANAMERecord(Name, Target string, TTL int) *Record {
+	return newRecord(RecordANAME, Name, Target, TTL)
+}
+
+// NewMXRecord returns an MX record sending mail for Name to Host, at the given preference Level
+func NewMXRecord(Name, Host string, Level, TTL int) *Record {
+	thisRecord := newRecord(RecordMX, Name, Host, TTL)
+	thisRecord.MxLevel = Level
+	return thisRecord
+}
+
+// NewNSRecord returns an NS record delegating Name to the name server Host
+func NewNSRecord(Name, Host string, TTL int) *Record {
+	return newRecord(RecordNS, Name, Host, TTL)
+}
+
+// NewPTRRecord returns a PTR record pointing Name back at Host
+func NewPTRRecord(Name, Host string, TTL int) *Record {
+	return newRecord(RecordPTR, Name, Host, TTL)
+}
+
+// NewSRVRecord returns an SRV record for the service Name (e.g. "_sip._tcp")
+func NewSRVRecord(Name string, Service SRVData, TTL int) *Record {
+	thisRecord := newRecord(RecordSRV, Name, Service.Target, TTL)
+	thisRecord.Priority = Service.Priority
+	thisRecord.Weight = Service.Weight
+	thisRecord.Port = Service.Port
+	return thisRecord
+}
+
+// NewTXTRecord returns a TXT record holding Text. The text is quoted for you, and split up if it is longer than 255 bytes (see QuoteTXT).
+func NewTXTRecord(Name, Text string, TTL int) *Record {
+	return newRecord(RecordTXT, Name, QuoteTXT(Text), TTL)
+}
+
+// NewSPFRecord returns an SPF record holding Text, quoted the same as NewTXTRecord
+func NewSPFRecord(Name, Text string, TTL int) *Record {
+	return newRecord(RecordSPF, Name, QuoteTXT(Text), TTL)
+}
+
+// NewCAARecord returns a CAA record, which limits the certificate authorities that can issue certificates for Name
+func NewCAARecord(Name string, Authority CAAData, TTL int) *Record {
+	thisRecord := newRecord(RecordCAA, Name, Authority.Value, TTL)
+	thisRecord.CaaType = Authority.Tag
+	if Authority.Critical {
+		thisRecord.IssuerCritical = CAACritical
+	}
+	return thisRecord
+}
+
+// NewHTTPRedirect returns an HTTPRED record, which DNS Made Easy serves as an HTTP redirect from Name to Redirect.URL
+func NewHTTPRedirect(Name string, Redirect HTTPRedirect, TTL int) *Record {
+	thisRecord := newRecord(RecordHTTPRED, Name, Redirect.URL, TTL)
+	thisRecord.RedirectType = Redirect.RedirectType
+	thisRecord.HardLink = Redirect.HardLink
+	thisRecord.Title = Redirect.Title
+	thisRecord.Keywords = Redirect.Keywords
+	thisRecord.Description = Redirect.Description
+	return thisRecord
+}
+
+// All of the constructors start with the same basic record, in the default GTD location
+func newRecord(Type RecordType, Name, Value string, TTL int) *Record {
+	return &Record{
+		Type:        string(Type),
+		Name:        Name,
+		Value:       Value,
+		TTL:         TTL,
+		GtdLocation: GTDDefault,
+	}
+}
+
+// IP returns the address of an A or AAAA record
+func (r *Record) IP() (net.IP, error) {
+	if err := r.checkType(RecordA, RecordAAAA); err != nil {
+		return nil, err
+	}
+	ip := net.ParseIP(r.Value)
+	if ip == nil {
+		return nil, fmt.Errorf("%s record %q has an invalid IP address %q", r.Type, r.Name, r.Value)
+	}
+	return ip, nil
+}
+
+// Target returns the host that a CNAME, ANAME, NS or PTR record points at
+func (r *Record) Target() (string, error) {
+	if err := r.checkType(RecordCNAME, RecordANAME, RecordNS, RecordPTR); err != nil {
+		return "", err
+	}
+	return r.Value, nil
+}
+
+// MX returns the type-specific data of an MX record
+func (r *Record) MX() (*MXData, error) {
+	if err := r.checkType(RecordMX); err != nil {
+		return nil, err
+	}
+	return &MXData{Host: r.Value, Level: r.MxLevel}, nil
+}
+
+// SRV returns the type-specific data of an SRV record
+func (r *Record) SRV() (*SRVData, error) {
+	if err := r.checkType(RecordSRV); err != nil {
+		return nil, err
+	}
+	return &SRVData{Target: r.Value, Priority: r.Priority, Weight: r.Weight, Port: r.Port}, nil
+}
+
+// Text returns the text of a TXT or SPF record, with the quotes removed and any split strings joined back together
+func (r *Record) Text() (string, error) {
+	if err := r.checkType(RecordTXT, RecordSPF); err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(r.Value, `"`) {
+		return r.Value, nil
+	}
+	chunks, ok := splitTXT(r.Value)
+	if !ok {
+		return "", fmt.Errorf("%s record %q has unbalanced quotes", r.Type, r.Name)
+	}
+	return strings.Join(chunks, ""), nil
+}
+
+// CAA returns the type-specific data of a CAA record
+func (r *Record) CAA() (*CAAData, error) {
+	if err := r.checkType(RecordCAA); err != nil {
+		return nil, err
+	}
+	return &CAAData{Critical: r.IssuerCritical == CAACritical, Tag: r.CaaType, Value: r.Value}, nil
+}
+
+// Redirect returns the type-specific data of an HTTPRED record
+func (r *Record) Redirect() (*HTTPRedirect, error) {
+	if err := r.checkType(RecordHTTPRED); err != nil {
+		return nil, err
+	}
+	return &HTTPRedirect{
+		URL:          r.Value,
+		RedirectType: r.RedirectType,
+		HardLink:     r.HardLink,
+		Title:        r.Title,
+		Keywords:     r.Keywords,
+		Description:  r.Description,
+	}, nil
+}
+
+// checkType makes sure an accessor is only used on the record types it understands
+func (r *Record) checkType(Types ...RecordType) error {
+	var typeNames []string
+	for _, t := range Types {
+		if RecordType(r.Type) == t {
+			return nil
+		}
+		typeNames = append(typeNames, string(t))
+	}
+	return fmt.Errorf("%s record %q is not of type %s", r.Type, r.Name, strings.Join(typeNames, " or "))
+}
@@ -0,0 +1,257 @@
+package GoDNSMadeEasy
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// RecordType is the type of a DNS record (A, MX, TXT, etc)
+type RecordType string
+
+// These are the record types supported by DNS Made Easy. HTTPRED is not a real DNS record, but an HTTP redirect served by DNS Made Easy.
+// They are untyped, so they can be used for Record.Type (a string) as well as a RecordType.
+const (
+	RecordA       = "A"
+	RecordAAAA    = "AAAA"
+	RecordCNAME   = "CNAME"
+	RecordANAME   = "ANAME"
+	RecordMX      = "MX"
+	RecordNS      = "NS"
+	RecordPTR     = "PTR"
+	RecordSRV     = "SRV"
+	RecordTXT     = "TXT"
+	RecordSPF     = "SPF"
+	RecordCAA     = "CAA"
+	RecordHTTPRED = "HTTPRED"
+)
+
+// RecordTypes is every record type supported by DNS Made Easy
+var RecordTypes = []RecordType{RecordA, RecordAAAA, RecordCNAME, RecordANAME, RecordMX, RecordNS, RecordPTR, RecordSRV, RecordTXT, RecordSPF, RecordCAA, RecordHTTPRED}
+
+// These are the redirect types supported by HTTPRED records
+const (
+	RedirectHidden    = "Hidden Frame Masked"
+	RedirectStandard  = "Standard - 302"
+	RedirectPermanent = "Standard - 301"
+)
+
+// These are the tags supported by CAA records (Record.CaaType)
+const (
+	CAAIssue     = "issue"
+	CAAIssueWild = "issuewild"
+	CAAIodef     = "iodef"
+)
+
+// CAACritical is the value of Record.IssuerCritical for a CAA record that certificate authorities must understand to issue a certificate
+const CAACritical = 128
+
+// maxTXTChunk is the longest string DNS allows in a single TXT record chunk. Longer values must be split into several quoted strings.
+const maxTXTChunk = 255
+
+// ErrInvalidRecord is returned (wrapped) by Record.Validate(), and by the methods that add or update records, when a record would be
+// rejected by DNS Made Easy. The wrapping error says what is wrong with it.
+var ErrInvalidRecord = errors.New("invalid record")
+
+// Valid reports whether t is one of the record types supported by DNS Made Easy
+func (t RecordType) Valid() bool {
+	for _, recordType := range RecordTypes {
+		if t == recordType {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks a record for mistakes that DNS Made Easy would reject, such as a malformed value or fields that don't belong to the
+// record's type. The error matches ErrInvalidRecord with errors.Is(). AddRecord, UpdateRecord and the bulk methods call this before
+// sending anything.
+func (r *Record) Validate() error {
+	problem := r.validate()
+	if problem == "" {
+		return nil
+	}
+	return fmt.Errorf("%s record %q: %s: %w", r.Type, r.Name, problem, ErrInvalidRecord)
+}
+
+// validate returns what is wrong with a record, or an empty string if it looks fine
+func (r *Record) validate() string {
+	if !RecordType(r.Type).Valid() {
+		return fmt.Sprintf("unknown record type %q", r.Type)
+	}
+	if !GTDLocation(r.GtdLocation).Valid() {
+		return fmt.Sprintf("unknown GTD location %q", r.GtdLocation)
+	}
+	if r.TTL < 0 {
+		return fmt.Sprintf("TTL %v is negative", r.TTL)
+	}
+
+	//Only some types have these extra fields
+	if r.Type != RecordMX && r.MxLevel != 0 {
+		return "MxLevel is only used by MX records"
+	}
+	if r.Type != RecordSRV && (r.Priority != 0 || r.Weight != 0 || r.Port != 0) {
+		return "Priority, Weight and Port are only used by SRV records"
+	}
+	//HardLink is left out, as DNS Made Easy returns it set on other types of record too
+	if r.Type != RecordHTTPRED && (r.RedirectType != "" || r.Title != "" || r.Keywords != "" || r.Description != "") {
+		return "RedirectType, Title, Keywords and Description are only used by HTTPRED records"
+	}
+	if r.Type != RecordCAA && (r.CaaType != "" || r.IssuerCritical != 0) {
+		return "CaaType and IssuerCritical are only used by CAA records"
+	}
+
+	switch r.Type {
+	case RecordA:
+		if ip := net.ParseIP(r.Value); ip == nil || ip.To4() == nil {
+			return fmt.Sprintf("value %q is not an IPv4 address", r.Value)
+		}
+	case RecordAAAA:
+		if ip := net.ParseIP(r.Value); ip == nil || !strings.Contains(r.Value, ":") {
+			return fmt.Sprintf("value %q is not an IPv6 address", r.Value)
+		}
+	case RecordCNAME, RecordANAME, RecordNS, RecordPTR:
+		if !validHostname(r.Value) {
+			return fmt.Sprintf("value %q is not a hostname", r.Value)
+		}
+	case RecordMX:
+		if !validHostname(r.Value) {
+			return fmt.Sprintf("value %q is not a hostname", r.Value)
+		}
+		if r.MxLevel < 0 || r.MxLevel > 65535 {
+			return fmt.Sprintf("MxLevel %v is not between 0 and 65535", r.MxLevel)
+		}
+	case RecordSRV:
+		if !validHostname(r.Value) {
+			return fmt.Sprintf("value %q is not a hostname", r.Value)
+		}
+		for _, field := range []struct {
+			name  string
+			value int
+		}{{"Priority", r.Priority}, {"Weight", r.Weight}, {"Port", r.Port}} {
+			if field.value < 0 || field.value > 65535 {
+				return fmt.Sprintf("%s %v is not between 0 and 65535", field.name, field.value)
+			}
+		}
+	case RecordTXT, RecordSPF:
+		return validateTXT(r.Value)
+	case RecordCAA:
+		if r.IssuerCritical != 0 && r.IssuerCritical != CAACritical {
+			return fmt.Sprintf("IssuerCritical %v is not 0 or %v", r.IssuerCritical, CAACritical)
+		}
+		if r.CaaType != CAAIssue && r.CaaType != CAAIssueWild && r.CaaType != CAAIodef {
+			return fmt.Sprintf("CaaType %q is not %s, %s or %s", r.CaaType, CAAIssue, CAAIssueWild, CAAIodef)
+		}
+		if r.Value == "" {
+			return "value is empty"
+		}
+	case RecordHTTPRED:
+		u, err := url.Parse(r.Value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Sprintf("value %q is not an http or https URL", r.Value)
+		}
+		if r.RedirectType != "" && !strings.EqualFold(r.RedirectType, RedirectHidden) && !strings.EqualFold(r.RedirectType, RedirectStandard) &&
+			!strings.EqualFold(r.RedirectType, RedirectPermanent) {
+			return fmt.Sprintf("RedirectType %q is not %q, %q or %q", r.RedirectType, RedirectHidden, RedirectStandard, RedirectPermanent)
+		}
+	}
+	return ""
+}
+
+// Hostnames can be relative to the domain or fully qualified (with a trailing dot). Underscores are allowed, as they are common in
+// service names, even though they aren't strictly valid in hostnames.
+func validHostname(Name string) bool {
+	Name = strings.TrimSuffix(Name, ".")
+	if Name == "" || len(Name) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(Name, ".") {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// validateTXT checks a TXT (or SPF) value. This can be a single unquoted string, or one or more quoted strings separated by spaces.
+// Either way, no string can be longer than 255 bytes. Use QuoteTXT() to split a long value up.
+func validateTXT(Value string) string {
+	if Value == "" {
+		return "value is empty"
+	}
+	if !strings.HasPrefix(Value, `"`) {
+		if len(Value) > maxTXTChunk {
+			return fmt.Sprintf("value is %v bytes, which is longer than %v bytes and needs splitting into quoted strings (see QuoteTXT)", len(Value), maxTXTChunk)
+		}
+		return ""
+	}
+
+	chunks, ok := splitTXT(Value)
+	if !ok {
+		return fmt.Sprintf("value %q has unbalanced quotes", Value)
+	}
+	for i, chunk := range chunks {
+		if len(chunk) > maxTXTChunk {
+			return fmt.Sprintf("quoted string %v is %v bytes, which is longer than %v bytes", i+1, len(chunk), maxTXTChunk)
+		}
+	}
+	return ""
+}
+
+// splitTXT splits a TXT value made of quoted strings into the strings themselves, without their quotes or escaping. It returns false
+// if the quotes don't match up, or there is anything other than spaces between the quoted strings.
+func splitTXT(Value string) ([]string, bool) {
+	var chunks []string
+	var chunk strings.Builder
+	inQuotes, escaped := false, false
+	for _, c := range Value {
+		switch {
+		case escaped:
+			chunk.WriteRune(c)
+			escaped = false
+		case inQuotes && c == '\\':
+			escaped = true
+		case c == '"':
+			if inQuotes {
+				chunks = append(chunks, chunk.String())
+				chunk.Reset()
+			}
+			inQuotes = !inQuotes
+		case inQuotes:
+			chunk.WriteRune(c)
+		case c != ' ' && c != '\t':
+			return nil, false
+		}
+	}
+	return chunks, !inQuotes && !escaped
+}
+
+// QuoteTXT turns any text into a value for a TXT or SPF record. Quotes and backslashes are escaped, and text longer than 255 bytes is
+// split into several quoted strings, which DNS clients join back together.
+func QuoteTXT(Text string) string {
+	var chunks []string
+	for {
+		//Don't split in the middle of a multi-byte character
+		cut := len(Text)
+		if cut > maxTXTChunk {
+			cut = maxTXTChunk
+			for cut > 0 && !utf8.RuneStart(Text[cut]) {
+				cut--
+			}
+		}
+		chunk := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(Text[:cut])
+		chunks = append(chunks, `"`+chunk+`"`)
+		Text = Text[cut:]
+		if Text == "" {
+			return strings.Join(chunks, " ")
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package GoDNSMadeEasy
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how requests that fail for temporary reasons are retried. The zero value never retries anything.
+//
+// Be aware that a POST which fails part-way through (such as a dropped connection) may have been applied by DNS Made Easy anyway,
+// in which case the retry will fail with an error matching IsDuplicate().
+type RetryPolicy struct {
+	// MaxAttempts is the total number of times a request will be sent, including the first. 0 or 1 means requests are never retried.
+	MaxAttempts int
+	// InitialBackoff is how long to wait before the first retry. This doubles for every retry after that. If omitted, this defaults to 1 second.
+	InitialBackoff time.Duration
+	// MaxBackoff is the longest we will wait between retries. If omitted, this defaults to 1 minute.
+	MaxBackoff time.Duration
+	// Retryable decides whether a failed request should be tried again. If omitted, this defaults to DefaultRetryable.
+	Retryable func(error) bool
+}
+
+// DefaultRetryPolicy returns a retry policy suitable for most uses: up to 5 attempts, starting 1 second apart and backing off to 1 minute,
+// retrying anything DefaultRetryable considers temporary.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+	}
+}
+
+// DefaultRetryable reports whether err is likely to go away if the request is tried again. This is true for 5xx server errors,
+// rate limiting, pending actions, and connections that were reset or dropped before a response was received.
+func DefaultRetryable(err error) bool {
+	var apiError *APIError
+	if errors.As(err, &apiError) {
+		return apiError.StatusCode >= http.StatusInternalServerError || IsRateLimited(err) || IsPendingAction(err)
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+	var netError net.Error
+	return errors.As(err, &netError) && netError.Timeout()
+}
+
+func (rp RetryPolicy) retryable(err error) bool {
+	if rp.Retryable != nil {
+		return rp.Retryable(err)
+	}
+	return DefaultRetryable(err)
+}
+
+// backoff returns how long to wait after the given (1-based) attempt has failed. This is exponential, with jitter so that clients
+// that failed at the same time don't all retry at the same time.
+func (rp RetryPolicy) backoff(attempt int) time.Duration {
+	initial, maxWait := rp.InitialBackoff, rp.MaxBackoff
+	if initial <= 0 {
+		initial = time.Second
+	}
+	if maxWait <= 0 {
+		maxWait = time.Minute
+	}
+
+	wait := initial
+	for i := 1; i < attempt && wait < maxWait; i++ {
+		wait *= 2
+	}
+	if wait > maxWait {
+		wait = maxWait
+	}
+
+	//Wait somewhere between half and all of the calculated backoff
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
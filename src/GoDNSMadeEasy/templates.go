@@ -0,0 +1,198 @@
+package GoDNSMadeEasy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Templates returns the record templates for an account. A template is a standard set of records that can be applied to many domains.
+func (dme *GoDMEConfig) Templates() ([]Template, error) {
+	return dme.TemplatesContext(context.Background())
+}
+
+// TemplatesContext is the same as Templates(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) TemplatesContext(ctx context.Context) ([]Template, error) {
+	templateData := []Template{}
+	err := dme.eachPage(ctx, "dns/template", func(pageData json.RawMessage) error {
+		pageTemplates := []Template{}
+		err := json.Unmarshal(pageData, &pageTemplates)
+		templateData = append(templateData, pageTemplates...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return templateData, nil
+}
+
+// Template returns a single record template (identified by its ID)
+func (dme *GoDMEConfig) Template(TemplateID int) (*Template, error) {
+	return dme.TemplateContext(context.Background(), TemplateID)
+}
+
+// TemplateContext is the same as Template(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) TemplateContext(ctx context.Context, TemplateID int) (*Template, error) {
+	reqStub := fmt.Sprintf("dns/template/%v", TemplateID)
+	req, err := dme.newRequest(ctx, "GET", reqStub, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	templateResponse := &Template{}
+	err = dme.doDMERequest(req, templateResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return templateResponse, nil
+}
+
+// TemplateRecords returns the records in a given record template
+func (dme *GoDMEConfig) TemplateRecords(TemplateID int) ([]Record, error) {
+	return dme.TemplateRecordsContext(context.Background(), TemplateID)
+}
+
+// TemplateRecordsContext is the same as TemplateRecords(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) TemplateRecordsContext(ctx context.Context, TemplateID int) ([]Record, error) {
+	reqStub := fmt.Sprintf("dns/template/%v/records", TemplateID)
+	recordData := []Record{}
+	err := dme.eachRecordPage(ctx, reqStub, func(thisRecord Record) error {
+		recordData = append(recordData, thisRecord)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recordData, nil
+}
+
+// AddTemplate creates a record template for an account. Records can then be added to it with AddTemplateRecord().
+func (dme *GoDMEConfig) AddTemplate(newTemplate Template) (*Template, error) {
+	return dme.AddTemplateContext(context.Background(), newTemplate)
+}
+
+// AddTemplateContext is the same as AddTemplate(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) AddTemplateContext(ctx context.Context, newTemplate Template) (*Template, error) {
+	bodyData, err := json.Marshal(newTemplate)
+	if err != nil {
+		return nil, err
+	}
+	bodyBuffer := bytes.NewReader(bodyData)
+	req, err := dme.newRequest(ctx, "POST", "dns/template", bodyBuffer)
+	if err != nil {
+		return nil, err
+	}
+
+	returnedTemplate := &Template{}
+	err = dme.doDMERequest(req, returnedTemplate)
+	if err != nil {
+		return nil, err
+	}
+	return returnedTemplate, err
+}
+
+// AddTemplateRecord adds a record to a given record template (identified by its ID)
+func (dme *GoDMEConfig) AddTemplateRecord(TemplateID int, TemplateRecord *Record) (*Record, error) {
+	return dme.AddTemplateRecordContext(context.Background(), TemplateID, TemplateRecord)
+}
+
+// AddTemplateRecordContext is the same as AddTemplateRecord(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) AddTemplateRecordContext(ctx context.Context, TemplateID int, TemplateRecord *Record) (*Record, error) {
+	err := TemplateRecord.Validate()
+	if err != nil {
+		return nil, err
+	}
+	reqStub := fmt.Sprintf("dns/template/%v/records", TemplateID)
+	bodyData, err := json.Marshal(TemplateRecord)
+	if err != nil {
+		return nil, err
+	}
+	bodyBuffer := bytes.NewReader(bodyData)
+	req, err := dme.newRequest(ctx, "POST", reqStub, bodyBuffer)
+	if err != nil {
+		return nil, err
+	}
+
+	returnedRecord := &Record{}
+	err = dme.doDMERequest(req, returnedRecord)
+	if err != nil {
+		return nil, err
+	}
+	return returnedRecord, err
+}
+
+// UpdateTemplate updates an existing record template (identified by its ID) for your account. DNS Made Easy only returns success/fail for this method.
+func (dme *GoDMEConfig) UpdateTemplate(Template *Template) error {
+	return dme.UpdateTemplateContext(context.Background(), Template)
+}
+
+// UpdateTemplateContext is the same as UpdateTemplate(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) UpdateTemplateContext(ctx context.Context, Template *Template) error {
+	reqStub := fmt.Sprintf("dns/template/%v", Template.ID)
+	bodyData, err := json.Marshal(Template)
+	if err != nil {
+		return err
+	}
+	return dme.genericUpdate(ctx, reqStub, bodyData)
+}
+
+// UpdateTemplateRecord updates an existing record (identified by its ID) in a given record template. DNS Made Easy only returns success/fail for this method.
+func (dme *GoDMEConfig) UpdateTemplateRecord(TemplateID int, TemplateRecord *Record) error {
+	return dme.UpdateTemplateRecordContext(context.Background(), TemplateID, TemplateRecord)
+}
+
+// UpdateTemplateRecordContext is the same as UpdateTemplateRecord(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) UpdateTemplateRecordContext(ctx context.Context, TemplateID int, TemplateRecord *Record) error {
+	err := TemplateRecord.Validate()
+	if err != nil {
+		return err
+	}
+	reqStub := fmt.Sprintf("dns/template/%v/records/%v", TemplateID, TemplateRecord.ID)
+	bodyData, err := json.Marshal(TemplateRecord)
+	if err != nil {
+		return err
+	}
+	return dme.genericUpdate(ctx, reqStub, bodyData)
+}
+
+// ApplyTemplate assigns a record template (identified by its ID) to one or more domains in a single request. The domains' records are
+// then managed by the template. DNS Made Easy only returns success/fail for this method.
+func (dme *GoDMEConfig) ApplyTemplate(TemplateID int, DomainIDs []int) error {
+	return dme.ApplyTemplateContext(context.Background(), TemplateID, DomainIDs)
+}
+
+// ApplyTemplateContext is the same as ApplyTemplate(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) ApplyTemplateContext(ctx context.Context, TemplateID int, DomainIDs []int) error {
+	//Updating dns/managed/ without an ID updates every domain listed in "ids"
+	bodyData, err := json.Marshal(struct {
+		IDs        []int `json:"ids"`
+		TemplateID int   `json:"templateId"`
+	}{DomainIDs, TemplateID})
+	if err != nil {
+		return err
+	}
+	return dme.genericUpdate(ctx, "dns/managed/", bodyData)
+}
+
+// DeleteTemplate deletes an existing record template (identified by its ID). The template must not be in use before deleting.
+func (dme *GoDMEConfig) DeleteTemplate(TemplateID int) error {
+	return dme.DeleteTemplateContext(context.Background(), TemplateID)
+}
+
+// DeleteTemplateContext is the same as DeleteTemplate(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) DeleteTemplateContext(ctx context.Context, TemplateID int) error {
+	return dme.genericDelete(ctx, fmt.Sprintf("dns/template/%v", TemplateID), 0)
+}
+
+// DeleteTemplateRecord deletes an existing record (identified by its ID) from a given record template
+func (dme *GoDMEConfig) DeleteTemplateRecord(TemplateID, RecordID int) error {
+	return dme.DeleteTemplateRecordContext(context.Background(), TemplateID, RecordID)
+}
+
+// DeleteTemplateRecordContext is the same as DeleteTemplateRecord(), but the request can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) DeleteTemplateRecordContext(ctx context.Context, TemplateID, RecordID int) error {
+	return dme.genericDelete(ctx, fmt.Sprintf("dns/template/%v/records/%v", TemplateID, RecordID), 0)
+}
@@ -0,0 +1,93 @@
+package GoDNSMadeEasy
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// DNS Made Easy sends its timestamps as milliseconds since the Unix epoch. A missing timestamp is turned into the zero time.Time,
+// so that IsZero() works as expected.
+func epochMillis(Millis int64) time.Time {
+	if Millis == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(Millis).UTC()
+}
+
+// CreatedTime returns Domain.Created as a time.Time (in UTC)
+func (d *Domain) CreatedTime() time.Time {
+	return epochMillis(d.Created)
+}
+
+// UpdatedTime returns Domain.Updated as a time.Time (in UTC)
+func (d *Domain) UpdatedTime() time.Time {
+	return epochMillis(d.Updated)
+}
+
+// CreatedTime returns SecondaryDomain.Created as a time.Time (in UTC)
+func (s *SecondaryDomain) CreatedTime() time.Time {
+	return epochMillis(s.Created)
+}
+
+// UpdatedTime returns SecondaryDomain.Updated as a time.Time (in UTC)
+func (s *SecondaryDomain) UpdatedTime() time.Time {
+	return epochMillis(s.Updated)
+}
+
+// SortDomainsByUpdated sorts domains by when they were last updated, oldest first
+func SortDomainsByUpdated(Domains []Domain) {
+	sort.SliceStable(Domains, func(i, j int) bool {
+		return Domains[i].Updated < Domains[j].Updated
+	})
+}
+
+// SortSecondaryDomainsByUpdated sorts secondary domains by when they were last updated, oldest first
+func SortSecondaryDomainsByUpdated(SecondaryDomains []SecondaryDomain) {
+	sort.SliceStable(SecondaryDomains, func(i, j int) bool {
+		return SecondaryDomains[i].Updated < SecondaryDomains[j].Updated
+	})
+}
+
+// DomainsUpdatedSince returns the domains that have been updated after Since, oldest first. This is handy for only looking at the
+// domains that have changed since the last time you checked.
+func (dme *GoDMEConfig) DomainsUpdatedSince(Since time.Time) ([]Domain, error) {
+	return dme.DomainsUpdatedSinceContext(context.Background(), Since)
+}
+
+// DomainsUpdatedSinceContext is the same as DomainsUpdatedSince(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) DomainsUpdatedSinceContext(ctx context.Context, Since time.Time) ([]Domain, error) {
+	domainData := []Domain{}
+	err := dme.EachDomainContext(ctx, func(thisDomain Domain) error {
+		if thisDomain.UpdatedTime().After(Since) {
+			domainData = append(domainData, thisDomain)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	SortDomainsByUpdated(domainData)
+	return domainData, nil
+}
+
+// SecondaryDomainsUpdatedSince returns the secondary domains that have been updated after Since, oldest first
+func (dme *GoDMEConfig) SecondaryDomainsUpdatedSince(Since time.Time) ([]SecondaryDomain, error) {
+	return dme.SecondaryDomainsUpdatedSinceContext(context.Background(), Since)
+}
+
+// SecondaryDomainsUpdatedSinceContext is the same as SecondaryDomainsUpdatedSince(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) SecondaryDomainsUpdatedSinceContext(ctx context.Context, Since time.Time) ([]SecondaryDomain, error) {
+	secondaryDomains := []SecondaryDomain{}
+	err := dme.EachSecondaryDomainContext(ctx, func(thisSecondaryDomain SecondaryDomain) error {
+		if thisSecondaryDomain.UpdatedTime().After(Since) {
+			secondaryDomains = append(secondaryDomains, thisSecondaryDomain)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	SortSecondaryDomainsByUpdated(secondaryDomains)
+	return secondaryDomains, nil
+}
@@ -0,0 +1,87 @@
+package GoDNSMadeEasy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Usage returns the number of DNS queries answered for the whole account, one QueryUsage per month
+func (dme *GoDMEConfig) Usage() ([]QueryUsage, error) {
+	return dme.UsageContext(context.Background())
+}
+
+// UsageContext is the same as Usage(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) UsageContext(ctx context.Context) ([]QueryUsage, error) {
+	return dme.usage(ctx, "usageApi/queriesApi/")
+}
+
+// UsageForMonth returns the number of DNS queries answered in a given month, one QueryUsage per domain (or secondary domain)
+func (dme *GoDMEConfig) UsageForMonth(Year int, Month time.Month) ([]QueryUsage, error) {
+	return dme.UsageForMonthContext(context.Background(), Year, Month)
+}
+
+// UsageForMonthContext is the same as UsageForMonth(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) UsageForMonthContext(ctx context.Context, Year int, Month time.Month) ([]QueryUsage, error) {
+	return dme.usage(ctx, fmt.Sprintf("usageApi/queriesApi/%v/%v", Year, int(Month)))
+}
+
+// DomainUsage returns the number of DNS queries answered for a single domain (identified by its ID) in a given month
+func (dme *GoDMEConfig) DomainUsage(DomainID, Year int, Month time.Month) ([]QueryUsage, error) {
+	return dme.DomainUsageContext(context.Background(), DomainID, Year, Month)
+}
+
+// DomainUsageContext is the same as DomainUsage(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) DomainUsageContext(ctx context.Context, DomainID, Year int, Month time.Month) ([]QueryUsage, error) {
+	return dme.usage(ctx, fmt.Sprintf("usageApi/queriesApi/%v/%v/managed/%v", Year, int(Month), DomainID))
+}
+
+// UsageByDomain returns the total number of DNS queries answered in a given month for every domain returned by Domains(), keyed by
+// domain name. Domains that answered no queries are included with a total of 0.
+func (dme *GoDMEConfig) UsageByDomain(Year int, Month time.Month) (map[string]int64, error) {
+	return dme.UsageByDomainContext(context.Background(), Year, Month)
+}
+
+// UsageByDomainContext is the same as UsageByDomain(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) UsageByDomainContext(ctx context.Context, Year int, Month time.Month) (map[string]int64, error) {
+	allDomains, err := dme.DomainsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	monthUsage, err := dme.UsageForMonthContext(ctx, Year, Month)
+	if err != nil {
+		return nil, err
+	}
+
+	//The usage only has the domain IDs, so add up the totals by ID and then match them to the names
+	totalsByID := make(map[int]int64)
+	for _, u := range monthUsage {
+		if u.PrimaryEntity != UsageEntityDomain {
+			continue
+		}
+		totalsByID[u.PrimaryEntityID] += u.Total
+	}
+
+	domainUsage := make(map[string]int64)
+	for _, domain := range allDomains {
+		domainUsage[domain.Name] = totalsByID[domain.ID]
+	}
+	return domainUsage, nil
+}
+
+// All of the usage endpoints return the same pages of QueryUsage
+func (dme *GoDMEConfig) usage(ctx context.Context, Endpoint string) ([]QueryUsage, error) {
+	usageData := []QueryUsage{}
+	err := dme.eachPage(ctx, Endpoint, func(pageData json.RawMessage) error {
+		pageUsage := []QueryUsage{}
+		err := json.Unmarshal(pageData, &pageUsage)
+		usageData = append(usageData, pageUsage...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return usageData, nil
+}
@@ -0,0 +1,91 @@
+package GoDNSMadeEasy
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultPendingActionPollInterval is how often WaitForDomain and WaitForSecondaryDomain check on a domain, unless
+// GoDMEConfig.PendingActionPollInterval says otherwise
+const DefaultPendingActionPollInterval = 5 * time.Second
+
+// WaitForDomain waits for DNS Made Easy to finish setting up (or changing) a domain, which it does in the background after AddDomain.
+// Until then, the domain has a PendingActionID and many changes to it will fail. The domain is checked every PendingActionPollInterval
+// for up to Timeout, and returned once it is ready. If it is still not ready after Timeout, the error matches IsPendingAction().
+func (dme *GoDMEConfig) WaitForDomain(DomainID int, Timeout time.Duration) (*Domain, error) {
+	return dme.WaitForDomainContext(context.Background(), DomainID, Timeout)
+}
+
+// WaitForDomainContext is the same as WaitForDomain(), but the wait can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) WaitForDomainContext(ctx context.Context, DomainID int, Timeout time.Duration) (*Domain, error) {
+	var thisDomain *Domain
+	err := dme.waitForPendingAction(ctx, fmt.Sprintf("domain %v", DomainID), Timeout, func() (int, error) {
+		var err error
+		thisDomain, err = dme.DomainContext(ctx, DomainID)
+		if err != nil {
+			return 0, err
+		}
+		return thisDomain.PendingActionID, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return thisDomain, nil
+}
+
+// WaitForSecondaryDomain waits for DNS Made Easy to finish setting up (or changing) a secondary domain, the same as WaitForDomain()
+func (dme *GoDMEConfig) WaitForSecondaryDomain(SecondaryDomainID int, Timeout time.Duration) (*SecondaryDomain, error) {
+	return dme.WaitForSecondaryDomainContext(context.Background(), SecondaryDomainID, Timeout)
+}
+
+// WaitForSecondaryDomainContext is the same as WaitForSecondaryDomain(), but the wait can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) WaitForSecondaryDomainContext(ctx context.Context, SecondaryDomainID int, Timeout time.Duration) (*SecondaryDomain, error) {
+	var thisSecondaryDomain *SecondaryDomain
+	err := dme.waitForPendingAction(ctx, fmt.Sprintf("secondary domain %v", SecondaryDomainID), Timeout, func() (int, error) {
+		var err error
+		thisSecondaryDomain, err = dme.SecondaryDomainContext(ctx, SecondaryDomainID)
+		if err != nil {
+			return 0, err
+		}
+		return thisSecondaryDomain.PendingActionID, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return thisSecondaryDomain, nil
+}
+
+// waitForPendingAction calls pendingAction until it returns a PendingActionID of 0, an error, or Timeout runs out
+func (dme *GoDMEConfig) waitForPendingAction(ctx context.Context, Description string, Timeout time.Duration, pendingAction func() (int, error)) error {
+	interval := dme.PendingActionPollInterval
+	if interval <= 0 {
+		interval = DefaultPendingActionPollInterval
+	}
+	timeOutAt := time.Now().Add(Timeout)
+
+	for {
+		pendingActionID, err := pendingAction()
+		if err != nil {
+			return err
+		}
+		if pendingActionID == 0 {
+			return nil
+		}
+		remaining := time.Until(timeOutAt)
+		if remaining <= 0 {
+			return fmt.Errorf("%s is still not ready after %s: %w", Description, Timeout, ErrPendingAction)
+		}
+
+		//Always have one last look right at the timeout, rather than giving up early
+		wait := interval
+		if wait > remaining {
+			wait = remaining
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
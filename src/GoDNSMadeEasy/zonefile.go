@@ -0,0 +1,220 @@
+package GoDNSMadeEasy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// defaultSOA is the SOA DNS Made Easy uses for domains that don't have a custom SOA. The serial changes with the domain, so is left out.
+var defaultSOA = SOA{
+	Comp:          "ns0.dnsmadeeasy.com.",
+	Email:         "dns.dnsmadeeasy.com.",
+	Refresh:       43200,
+	Retry:         3600,
+	Expire:        1209600,
+	NegativeCache: 180,
+	TTL:           21600,
+}
+
+// ExportDomain returns all of the data about a single domain (identified by its ID), the same as a single entry from ExportAllDomains()
+func (dme *GoDMEConfig) ExportDomain(DomainID int) (*DomainExport, error) {
+	return dme.ExportDomainContext(context.Background(), DomainID)
+}
+
+// ExportDomainContext is the same as ExportDomain(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) ExportDomainContext(ctx context.Context, DomainID int) (*DomainExport, error) {
+	thisDomain, err := dme.DomainContext(ctx, DomainID)
+	if err != nil {
+		return nil, err
+	}
+	thisRecords, err := dme.RecordsContext(ctx, DomainID)
+	if err != nil {
+		return nil, err
+	}
+	thisExport := &DomainExport{
+		Info:    thisDomain,
+		Records: &thisRecords,
+	}
+
+	//The custom SOA, vanity NS and transfer ACL can only be listed, so only fetch the ones the domain uses
+	if thisDomain.SoaID != 0 {
+		allSOA, err := dme.SOAContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for i := range allSOA {
+			if allSOA[i].ID == thisDomain.SoaID {
+				thisExport.SOA = &allSOA[i]
+			}
+		}
+	}
+	if thisDomain.VanityID != 0 {
+		allVanity, err := dme.VanityContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for i := range allVanity {
+			if allVanity[i].ID == thisDomain.VanityID {
+				thisExport.DefaultNS = &allVanity[i]
+			}
+		}
+	}
+	if thisDomain.TransferAclID != 0 {
+		allTransferACLs, err := dme.TransferACLsContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for i := range allTransferACLs {
+			if allTransferACLs[i].ID == thisDomain.TransferAclID {
+				thisExport.TransferACL = &allTransferACLs[i]
+			}
+		}
+	}
+
+	return thisExport, nil
+}
+
+// ExportZoneFile returns a domain (identified by its ID) as a standard RFC 1035 master file, as used by BIND. See DomainExport.WriteZone()
+// for what is included.
+func (dme *GoDMEConfig) ExportZoneFile(DomainID int) ([]byte, error) {
+	return dme.ExportZoneFileContext(context.Background(), DomainID)
+}
+
+// ExportZoneFileContext is the same as ExportZoneFile(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) ExportZoneFileContext(ctx context.Context, DomainID int) ([]byte, error) {
+	thisExport, err := dme.ExportDomainContext(ctx, DomainID)
+	if err != nil {
+		return nil, err
+	}
+	zone := &bytes.Buffer{}
+	err = thisExport.WriteZone(zone)
+	if err != nil {
+		return nil, err
+	}
+	return zone.Bytes(), nil
+}
+
+// WriteZone writes the domain as a standard RFC 1035 master file, as used by BIND. The SOA is the domain's custom SOA, or DNS Made Easy's
+// default if it doesn't have one. The apex NS records are the vanity name servers, or DNS Made Easy's name servers if the domain doesn't
+// use vanity name servers. Record names and values are written relative to the domain unless DNS Made Easy has them fully qualified.
+//
+// ANAME and HTTPRED records, and records in a GTD location other than GTDDefault, have no standard equivalent, so they are written as
+// comments.
+func (e *DomainExport) WriteZone(w io.Writer) error {
+	if e.Info == nil {
+		return fmt.Errorf("domain export has no domain info")
+	}
+	origin := normaliseDomainName(e.Info.Name) + "."
+
+	soa := defaultSOA
+	if e.SOA != nil {
+		soa = *e.SOA
+	}
+	serial := int64(soa.Serial)
+	if serial == 0 {
+		//DNS Made Easy manages the serial of its default SOA, so the best we can do is the last time the domain changed
+		serial = e.Info.Updated / 1000
+	}
+
+	zw := &zoneWriter{w: w}
+	zw.printf("; %s exported from DNS Made Easy (domain ID %v)\n", origin, e.Info.ID)
+	zw.printf("$ORIGIN %s\n", origin)
+	zw.printf("$TTL %v\n", soa.TTL)
+	zw.printf("@\t%v\tIN\tSOA\t%s %s (\n", soa.TTL, absoluteName(soa.Comp), absoluteName(strings.Replace(soa.Email, "@", ".", 1)))
+	zw.printf("\t\t\t\t%v\t; serial\n", serial)
+	zw.printf("\t\t\t\t%v\t; refresh\n", soa.Refresh)
+	zw.printf("\t\t\t\t%v\t; retry\n", soa.Retry)
+	zw.printf("\t\t\t\t%v\t; expire\n", soa.Expire)
+	zw.printf("\t\t\t\t%v )\t; negative cache\n", soa.NegativeCache)
+
+	var nameServers []string
+	if e.DefaultNS != nil {
+		nameServers = e.DefaultNS.Servers
+	} else {
+		for _, ns := range e.Info.NameServers {
+			nameServers = append(nameServers, ns.Fqdn)
+		}
+	}
+	for _, ns := range nameServers {
+		zw.printf("@\t%v\tIN\tNS\t%s\n", soa.TTL, absoluteName(ns))
+	}
+
+	if e.Records != nil {
+		records := append([]Record{}, *e.Records...)
+		sort.SliceStable(records, func(i, j int) bool {
+			if records[i].Name != records[j].Name {
+				return records[i].Name < records[j].Name
+			}
+			return records[i].Type < records[j].Type
+		})
+		for _, thisRecord := range records {
+			zw.writeRecord(thisRecord)
+		}
+	}
+	return zw.err
+}
+
+// zoneWriter keeps hold of the first error while writing a zone, so that every line doesn't need checking
+type zoneWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (zw *zoneWriter) printf(format string, a ...interface{}) {
+	if zw.err != nil {
+		return
+	}
+	_, zw.err = fmt.Fprintf(zw.w, format, a...)
+}
+
+// writeRecord writes a single record in master file format, or as a comment if it can't be expressed in one
+func (zw *zoneWriter) writeRecord(r Record) {
+	name := r.Name
+	if name == "" {
+		name = "@"
+	}
+
+	if r.Type == RecordHTTPRED {
+		zw.printf("; HTTPRED (DNS Made Easy HTTP redirect): %s -> %s (%s)\n", name, r.Value, r.RedirectType)
+		return
+	}
+
+	line := fmt.Sprintf("%s\t%v\tIN\t%s\t%s\n", name, r.TTL, r.Type, recordData(r))
+	switch {
+	case r.Type == RecordANAME:
+		zw.printf("; ANAME (DNS Made Easy only, served as the A records of %s): %s", r.Value, line)
+	case !GTDLocation(r.GtdLocation).IsDefault():
+		zw.printf("; GTD location %s (DNS Made Easy only): %s", r.GtdLocation, line)
+	default:
+		zw.printf("%s", line)
+	}
+}
+
+// recordData returns the data part of a record as it is written in a master file, with any type-specific fields in front of the value
+func recordData(r Record) string {
+	switch r.Type {
+	case RecordMX:
+		return fmt.Sprintf("%v %s", r.MxLevel, r.Value)
+	case RecordSRV:
+		return fmt.Sprintf("%v %v %v %s", r.Priority, r.Weight, r.Port, r.Value)
+	case RecordTXT, RecordSPF:
+		if !strings.HasPrefix(r.Value, `"`) {
+			return QuoteTXT(r.Value)
+		}
+	case RecordCAA:
+		return fmt.Sprintf("%v %s %s", r.IssuerCritical, r.CaaType, QuoteTXT(r.Value))
+	}
+	return r.Value
+}
+
+// DNS Made Easy doesn't always put the trailing dot on names that are meant to be fully qualified (such as SOA and name servers)
+func absoluteName(Name string) string {
+	if strings.HasSuffix(Name, ".") {
+		return Name
+	}
+	return Name + "."
+}
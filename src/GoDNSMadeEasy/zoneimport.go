@@ -0,0 +1,537 @@
+package GoDNSMadeEasy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DefaultZoneTTL is the TTL given to records in a zone file that has no $TTL and doesn't give the record a TTL of its own
+const DefaultZoneTTL = 1800
+
+// ZoneImportOptions changes how a zone file is read by ParseZone() and ImportZoneFile()
+type ZoneImportOptions struct {
+	// Origin is the domain the zone file is for, which relative names are relative to until a $ORIGIN says otherwise. ImportZoneFile
+	// defaults this to the name of the domain being imported into.
+	Origin string
+	// DefaultTTL is the TTL for records that don't have one, if the zone file has no $TTL. If omitted, this defaults to DefaultZoneTTL
+	DefaultTTL int
+	// IncludeRoot is the directory that $INCLUDE files are read from. Files outside this directory can't be included. If omitted,
+	// any $INCLUDE is an error.
+	IncludeRoot string
+	// DryRun makes ImportZoneFile only parse the zone file and report what it would create, without creating anything
+	DryRun bool
+}
+
+// ZoneImport is the result of reading a zone file
+type ZoneImport struct {
+	// Records are the records found in the zone file, named the way DNS Made Easy names them (relative to the domain, with an empty
+	// name for the domain itself). After ImportZoneFile, these are the records as created by DNS Made Easy (unless DryRun was set).
+	Records []Record
+	// Skipped are the records in the zone file that can't be imported, and why
+	Skipped []ZoneSkip
+}
+
+// ZoneSkip is a record in a zone file that was not imported
+type ZoneSkip struct {
+	// File is the zone file the record came from. This is empty for the file passed in, and the path of the file for an $INCLUDE.
+	File string
+	// Line is the line number the record starts on
+	Line int
+	// Text is the record as it appeared in the zone file, minus comments
+	Text string
+	// Reason is why the record was not imported
+	Reason string
+}
+
+// ImportZoneFile reads a standard RFC 1035 master file (as used by BIND) and adds its records to a domain (identified by its ID). The SOA
+// and the domain's own NS records are skipped, as DNS Made Easy manages those itself, along with anything else that can't be imported
+// (see ParseZone). The records are added in bulk, so if any are rejected the error is a *BulkError.
+func (dme *GoDMEConfig) ImportZoneFile(DomainID int, Zone io.Reader, Options ZoneImportOptions) (*ZoneImport, error) {
+	return dme.ImportZoneFileContext(context.Background(), DomainID, Zone, Options)
+}
+
+// ImportZoneFileContext is the same as ImportZoneFile(), but the requests can be cancelled or given a deadline with ctx
+func (dme *GoDMEConfig) ImportZoneFileContext(ctx context.Context, DomainID int, Zone io.Reader, Options ZoneImportOptions) (*ZoneImport, error) {
+	if Options.Origin == "" {
+		thisDomain, err := dme.DomainContext(ctx, DomainID)
+		if err != nil {
+			return nil, err
+		}
+		Options.Origin = thisDomain.Name
+	}
+
+	thisImport, err := ParseZone(Zone, Options)
+	if err != nil {
+		return nil, err
+	}
+	if Options.DryRun || len(thisImport.Records) == 0 {
+		return thisImport, nil
+	}
+
+	thisImport.Records, err = dme.AddRecordsContext(ctx, DomainID, thisImport.Records)
+	return thisImport, err
+}
+
+// ParseZone reads a standard RFC 1035 master file (as used by BIND), including $ORIGIN, $TTL and $INCLUDE, and returns the records in
+// it named the way DNS Made Easy names them. Options.Origin must be set to the domain the zone is for.
+//
+// The SOA, NS records for the domain itself, records outside the domain, record types DNS Made Easy doesn't support, and records that
+// fail Record.Validate() are not returned in Records, but listed in Skipped. Errors are only returned for zone files that can't be read
+// at all.
+func ParseZone(Zone io.Reader, Options ZoneImportOptions) (*ZoneImport, error) {
+	if Options.Origin == "" {
+		return nil, fmt.Errorf("zone origin is blank")
+	}
+	if Options.DefaultTTL <= 0 {
+		Options.DefaultTTL = DefaultZoneTTL
+	}
+
+	zp := &zoneParser{
+		domain:  normaliseDomainName(Options.Origin),
+		options: Options,
+		result:  &ZoneImport{},
+	}
+	zp.origin = zp.domain
+	err := zp.parse(Zone, "")
+	if err != nil {
+		return nil, err
+	}
+	return zp.result, nil
+}
+
+// zoneParser holds the state that carries on from one zone file entry to the next
+type zoneParser struct {
+	domain  string
+	options ZoneImportOptions
+	result  *ZoneImport
+	//origin is the current $ORIGIN, without a trailing dot
+	origin string
+	//ttl is the current $TTL, or 0 if there hasn't been one
+	ttl int
+	//lastTTL and lastOwner are used by entries that leave out their TTL or owner name
+	lastTTL   int
+	lastOwner string
+	//includeDepth stops $INCLUDE files including each other forever
+	includeDepth int
+}
+
+// zoneToken is a single word or quoted string from a zone file
+type zoneToken struct {
+	text   string
+	quoted bool
+}
+
+// zoneEntry is a directive or record from a zone file, which may have been split over several lines with parentheses
+type zoneEntry struct {
+	line int
+	//blankOwner is set when the entry starts with whitespace, meaning it has the same owner as the entry before
+	blankOwner bool
+	tokens     []zoneToken
+}
+
+func (e zoneEntry) String() string {
+	var words []string
+	for _, token := range e.tokens {
+		if token.quoted {
+			words = append(words, QuoteTXT(token.text))
+		} else {
+			words = append(words, token.text)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func (zp *zoneParser) parse(Zone io.Reader, FileName string) error {
+	data, err := ioutil.ReadAll(Zone)
+	if err != nil {
+		return err
+	}
+	entries, err := splitZoneEntries(string(data))
+	if err != nil {
+		return fmt.Errorf("%s%w", fileLabel(FileName), err)
+	}
+
+	for _, entry := range entries {
+		err := zp.parseEntry(entry, FileName)
+		if err != nil {
+			return fmt.Errorf("%sline %v: %w", fileLabel(FileName), entry.line, err)
+		}
+	}
+	return nil
+}
+
+func fileLabel(FileName string) string {
+	if FileName == "" {
+		return ""
+	}
+	return FileName + " "
+}
+
+func (zp *zoneParser) parseEntry(entry zoneEntry, FileName string) error {
+	tokens := entry.tokens
+	first := tokens[0]
+	if !entry.blankOwner && !first.quoted && strings.HasPrefix(first.text, "$") {
+		return zp.parseDirective(entry, FileName)
+	}
+
+	skip := func(reason string) {
+		zp.result.Skipped = append(zp.result.Skipped, ZoneSkip{File: FileName, Line: entry.line, Text: entry.String(), Reason: reason})
+	}
+
+	//The owner name can be left out to use the one from the entry before
+	owner := zp.lastOwner
+	if !entry.blankOwner {
+		owner = zp.absolute(first.text)
+		tokens = tokens[1:]
+	}
+	if owner == "" {
+		return fmt.Errorf("record has no owner name")
+	}
+	zp.lastOwner = owner
+
+	//Then the TTL and class, in either order, and both optional
+	ttl := -1
+	for len(tokens) > 0 && !tokens[0].quoted {
+		if seconds, err := parseZoneTTL(tokens[0].text); err == nil && ttl < 0 {
+			ttl = seconds
+		} else if class := strings.ToUpper(tokens[0].text); class == "IN" || class == "CH" || class == "HS" || class == "CS" {
+			if class != "IN" {
+				skip(fmt.Sprintf("class %s is not supported", class))
+				return nil
+			}
+		} else {
+			break
+		}
+		tokens = tokens[1:]
+	}
+	if ttl < 0 {
+		switch {
+		case zp.ttl > 0:
+			ttl = zp.ttl
+		case zp.lastTTL > 0:
+			ttl = zp.lastTTL
+		default:
+			ttl = zp.options.DefaultTTL
+		}
+	}
+	zp.lastTTL = ttl
+
+	if len(tokens) == 0 {
+		return fmt.Errorf("record has no type")
+	}
+	recordType := RecordType(strings.ToUpper(tokens[0].text))
+	rdata := tokens[1:]
+
+	name, inDomain := zp.relativeName(owner)
+	if !inDomain {
+		skip(fmt.Sprintf("%s. is outside of %s.", owner, zp.domain))
+		return nil
+	}
+	switch {
+	case recordType == "SOA":
+		skip("DNS Made Easy manages the SOA")
+		return nil
+	case recordType == RecordNS && name == "":
+		skip("DNS Made Easy manages the name servers for the domain")
+		return nil
+	case !recordType.Valid() || recordType == RecordANAME || recordType == RecordHTTPRED:
+		skip(fmt.Sprintf("record type %s is not supported", recordType))
+		return nil
+	}
+
+	thisRecord, err := zp.parseRData(recordType, rdata)
+	if err != nil {
+		skip(err.Error())
+		return nil
+	}
+	thisRecord.Name = name
+	thisRecord.TTL = ttl
+	thisRecord.GtdLocation = GTDDefault
+	if err := thisRecord.Validate(); err != nil {
+		skip(err.Error())
+		return nil
+	}
+	zp.result.Records = append(zp.result.Records, *thisRecord)
+	return nil
+}
+
+// parseRData turns the data part of a record into a Record, with its names made fully qualified
+func (zp *zoneParser) parseRData(Type RecordType, RData []zoneToken) (*Record, error) {
+	wantFields := func(n int) error {
+		if len(RData) != n {
+			return fmt.Errorf("%s record should have %v fields, but has %v", Type, n, len(RData))
+		}
+		return nil
+	}
+	wantNumbers := func(fields []zoneToken) ([]int, error) {
+		var numbers []int
+		for _, field := range fields {
+			n, err := strconv.Atoi(field.text)
+			if err != nil {
+				return nil, fmt.Errorf("%s record field %q is not a number", Type, field.text)
+			}
+			numbers = append(numbers, n)
+		}
+		return numbers, nil
+	}
+
+	switch Type {
+	case RecordA, RecordAAAA:
+		if err := wantFields(1); err != nil {
+			return nil, err
+		}
+		return &Record{Type: string(Type), Value: RData[0].text}, nil
+	case RecordCNAME, RecordNS, RecordPTR:
+		if err := wantFields(1); err != nil {
+			return nil, err
+		}
+		return &Record{Type: string(Type), Value: zp.absolute(RData[0].text) + "."}, nil
+	case RecordMX:
+		if err := wantFields(2); err != nil {
+			return nil, err
+		}
+		numbers, err := wantNumbers(RData[:1])
+		if err != nil {
+			return nil, err
+		}
+		return NewMXRecord("", zp.absolute(RData[1].text)+".", numbers[0], 0), nil
+	case RecordSRV:
+		if err := wantFields(4); err != nil {
+			return nil, err
+		}
+		numbers, err := wantNumbers(RData[:3])
+		if err != nil {
+			return nil, err
+		}
+		return NewSRVRecord("", SRVData{Target: zp.absolute(RData[3].text) + ".", Priority: numbers[0], Weight: numbers[1], Port: numbers[2]}, 0), nil
+	case RecordTXT, RecordSPF:
+		if len(RData) == 0 {
+			return nil, fmt.Errorf("%s record has no text", Type)
+		}
+		var chunks []string
+		for _, chunk := range RData {
+			chunks = append(chunks, QuoteTXT(chunk.text))
+		}
+		return &Record{Type: string(Type), Value: strings.Join(chunks, " ")}, nil
+	case RecordCAA:
+		if err := wantFields(3); err != nil {
+			return nil, err
+		}
+		numbers, err := wantNumbers(RData[:1])
+		if err != nil {
+			return nil, err
+		}
+		return NewCAARecord("", CAAData{Critical: numbers[0]&CAACritical != 0, Tag: strings.ToLower(RData[1].text), Value: RData[2].text}, 0), nil
+	}
+	return nil, fmt.Errorf("record type %s is not supported", Type)
+}
+
+func (zp *zoneParser) parseDirective(entry zoneEntry, FileName string) error {
+	directive := strings.ToUpper(entry.tokens[0].text)
+	args := entry.tokens[1:]
+	switch directive {
+	case "$ORIGIN":
+		if len(args) != 1 {
+			return fmt.Errorf("$ORIGIN should have 1 argument, but has %v", len(args))
+		}
+		zp.origin = zp.absolute(args[0].text)
+	case "$TTL":
+		if len(args) != 1 {
+			return fmt.Errorf("$TTL should have 1 argument, but has %v", len(args))
+		}
+		ttl, err := parseZoneTTL(args[0].text)
+		if err != nil {
+			return err
+		}
+		zp.ttl = ttl
+	case "$INCLUDE":
+		if len(args) != 1 && len(args) != 2 {
+			return fmt.Errorf("$INCLUDE should have 1 or 2 arguments, but has %v", len(args))
+		}
+		return zp.include(args[0].text, args, FileName)
+	default:
+		return fmt.Errorf("unknown directive %s", directive)
+	}
+	return nil
+}
+
+// include reads an $INCLUDE file. It can have its own origin, but that (and any $ORIGIN inside it) doesn't carry on after the include.
+func (zp *zoneParser) include(Path string, Args []zoneToken, FileName string) error {
+	if zp.options.IncludeRoot == "" {
+		return fmt.Errorf("$INCLUDE %s is not allowed without an IncludeRoot", Path)
+	}
+	if zp.includeDepth >= 10 {
+		return fmt.Errorf("$INCLUDE %s is nested too deeply", Path)
+	}
+	fullPath := filepath.Join(zp.options.IncludeRoot, Path)
+	relPath, err := filepath.Rel(zp.options.IncludeRoot, fullPath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("$INCLUDE %s is outside of the IncludeRoot", Path)
+	}
+	includeFile, err := os.Open(fullPath)
+	if err != nil {
+		return err
+	}
+	defer includeFile.Close()
+
+	oldOrigin := zp.origin
+	if len(Args) == 2 {
+		zp.origin = zp.absolute(Args[1].text)
+	}
+	zp.includeDepth++
+	err = zp.parse(includeFile, Path)
+	zp.includeDepth--
+	zp.origin = oldOrigin
+	return err
+}
+
+// absolute turns a name from the zone file into a fully qualified name, without the trailing dot
+func (zp *zoneParser) absolute(Name string) string {
+	switch {
+	case Name == "@":
+		return zp.origin
+	case strings.HasSuffix(Name, "."):
+		return strings.ToLower(strings.TrimSuffix(Name, "."))
+	case zp.origin == "":
+		return strings.ToLower(Name)
+	}
+	return strings.ToLower(Name + "." + zp.origin)
+}
+
+// relativeName turns a fully qualified name into a DNS Made Easy record name, which is relative to the domain. It returns false if the
+// name is not in the domain.
+func (zp *zoneParser) relativeName(Name string) (string, bool) {
+	if Name == zp.domain {
+		return "", true
+	}
+	if strings.HasSuffix(Name, "."+zp.domain) {
+		return strings.TrimSuffix(Name, "."+zp.domain), true
+	}
+	return "", false
+}
+
+// parseZoneTTL reads a TTL in seconds, or in BIND's units (e.g. 1h30m, 2d, 1w)
+func parseZoneTTL(TTL string) (int, error) {
+	if seconds, err := strconv.Atoi(TTL); err == nil && seconds >= 0 {
+		return seconds, nil
+	}
+	var total, current int
+	var sawDigit, sawUnit bool
+	for _, c := range strings.ToLower(TTL) {
+		if c >= '0' && c <= '9' {
+			current = current*10 + int(c-'0')
+			sawDigit = true
+			continue
+		}
+		unit := map[rune]int{'s': 1, 'm': 60, 'h': 3600, 'd': 86400, 'w': 604800}[c]
+		if unit == 0 || !sawDigit {
+			return 0, fmt.Errorf("%q is not a TTL", TTL)
+		}
+		total += current * unit
+		current, sawDigit, sawUnit = 0, false, true
+	}
+	if !sawUnit || sawDigit {
+		return 0, fmt.Errorf("%q is not a TTL", TTL)
+	}
+	return total, nil
+}
+
+// splitZoneEntries splits a zone file into its entries, joining up lines inside parentheses and dropping comments
+func splitZoneEntries(Zone string) ([]zoneEntry, error) {
+	var entries []zoneEntry
+	var entry zoneEntry
+	var token strings.Builder
+	var inToken, inQuotes bool
+	var parens int
+	line, startOfLine := 1, true
+
+	endToken := func() {
+		if inToken {
+			entry.tokens = append(entry.tokens, zoneToken{text: token.String(), quoted: inQuotes})
+			token.Reset()
+			inToken = false
+		}
+	}
+
+	runes := []rune(Zone)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		if startOfLine && parens == 0 {
+			entry = zoneEntry{line: line, blankOwner: c == ' ' || c == '\t'}
+		}
+		startOfLine = false
+
+		switch {
+		case c == '\\' && i+1 < len(runes):
+			//Either \DDD for a byte given in decimal, or a character that would otherwise mean something
+			if i+3 < len(runes) && isDigit(runes[i+1]) && isDigit(runes[i+2]) && isDigit(runes[i+3]) {
+				b, _ := strconv.Atoi(string(runes[i+1 : i+4]))
+				token.WriteByte(byte(b))
+				i += 3
+			} else {
+				token.WriteRune(runes[i+1])
+				i++
+			}
+			inToken = true
+		case inQuotes && c == '"':
+			endToken()
+			inQuotes = false
+		case inQuotes:
+			if c == '\n' {
+				return nil, fmt.Errorf("line %v: quoted string runs past the end of the line", line)
+			}
+			token.WriteRune(c)
+		case c == '"':
+			endToken()
+			inQuotes, inToken = true, true
+		case c == ';':
+			for i+1 < len(runes) && runes[i+1] != '\n' {
+				i++
+			}
+		case c == '(':
+			endToken()
+			parens++
+		case c == ')':
+			endToken()
+			if parens == 0 {
+				return nil, fmt.Errorf("line %v: unexpected )", line)
+			}
+			parens--
+		case c == '\n':
+			endToken()
+			if parens == 0 && len(entry.tokens) > 0 {
+				entries = append(entries, entry)
+				entry = zoneEntry{}
+			}
+			line++
+			startOfLine = true
+		case c == ' ' || c == '\t' || c == '\r':
+			endToken()
+		default:
+			token.WriteRune(c)
+			inToken = true
+		}
+	}
+
+	if inQuotes {
+		return nil, fmt.Errorf("line %v: quoted string is not closed", line)
+	}
+	if parens > 0 {
+		return nil, fmt.Errorf("line %v: ( is not closed", entry.line)
+	}
+	endToken()
+	if len(entry.tokens) > 0 {
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func isDigit(c rune) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,33 @@
+// Package zoneyaml reads and writes GoDNSMadeEasy zone configs as YAML. It is kept out of the GoDNSMadeEasy package so that only
+// programs that want YAML need gopkg.in/yaml.v3.
+package zoneyaml
+
+import (
+	"io"
+
+	"github.com/mhenderson-so/godnsmadeeasy/src/GoDNSMadeEasy"
+	"gopkg.in/yaml.v3"
+)
+
+// ReadZoneConfig reads a zone config written in YAML. Unknown fields are an error, to catch typos.
+func ReadZoneConfig(r io.Reader) (*GoDNSMadeEasy.ZoneConfig, error) {
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	thisConfig := &GoDNSMadeEasy.ZoneConfig{}
+	err := decoder.Decode(thisConfig)
+	if err != nil {
+		return nil, err
+	}
+	return thisConfig, thisConfig.CheckVersion()
+}
+
+// WriteZoneConfig writes a zone config as YAML
+func WriteZoneConfig(w io.Writer, Config *GoDNSMadeEasy.ZoneConfig) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	err := encoder.Encode(Config)
+	if err != nil {
+		return err
+	}
+	return encoder.Close()
+}
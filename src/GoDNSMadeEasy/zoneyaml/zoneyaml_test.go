@@ -0,0 +1,54 @@
+package zoneyaml
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mhenderson-so/godnsmadeeasy/src/GoDNSMadeEasy"
+)
+
+// TestZoneConfig checks that a config survives a round trip through YAML, using the same field names as the JSON format
+func TestZoneConfig(t *testing.T) {
+	thisConfig := &GoDNSMadeEasy.ZoneConfig{
+		Version: GoDNSMadeEasy.ZoneConfigVersion,
+		Domains: []GoDNSMadeEasy.DomainConfig{{
+			Name: "example.org",
+			SOA:  "my soa",
+			TTL:  300,
+			Records: map[string][]GoDNSMadeEasy.RecordConfig{
+				GoDNSMadeEasy.ApexName: {{Type: GoDNSMadeEasy.RecordMX, Value: "mail", Level: 10}},
+				"geo":                  {{Type: GoDNSMadeEasy.RecordA, Value: "127.0.0.2", Location: GoDNSMadeEasy.GTDEurope, DynamicDNS: true}},
+				"txt":                  {{Type: GoDNSMadeEasy.RecordTXT, Value: `some "quoted" text`, TTL: 86400}},
+			},
+		}},
+	}
+
+	configFile := &bytes.Buffer{}
+	err := WriteZoneConfig(configFile, thisConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"soa: my soa\n", "location: EUROPE\n", "dynamicDns: true\n"} {
+		if !strings.Contains(configFile.String(), expected) {
+			t.Errorf("config is missing %q:\n%s", expected, configFile.String())
+		}
+	}
+	readConfig, err := ReadZoneConfig(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(readConfig, thisConfig) {
+		t.Errorf("config changed in a round trip: %+v", readConfig)
+	}
+
+	_, err = ReadZoneConfig(strings.NewReader("version: 2\n"))
+	if err == nil {
+		t.Error("expected an error for an unknown version")
+	}
+	_, err = ReadZoneConfig(strings.NewReader("version: 1\ndomains:\n  - name: example.org\n    recrods: {}\n"))
+	if err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}